@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// ProveAbsence returns the proof that the given key is not in the trie, which
+// contains the nodes from the root down to the node where the key's path
+// diverges from the trie.
+// In tombstone mode, a deleted key is absent like for Get: the proof contains
+// the nodes down to the tombstone.
+// It returns nil and false if the key exists, or if its path reaches a node
+// only known by its hash.
+func (t *Trie) ProveAbsence(key []byte) (Proof, bool) {
+	proof := NewProofDB()
+	node := t.root
+	nibbles := FromBytes(key)
+
+	for {
+		if IsEmptyNode(node) {
+			return proof, true
+		}
+
+		if _, ok := node.(HashNode); ok {
+			return nil, false
+		}
+
+		proof.Put(Hash(node), Serialize(node))
+
+		if leaf, ok := node.(*LeafNode); ok {
+			matched := PrefixMatchedLen(leaf.Path, nibbles)
+			if matched == len(leaf.Path) && matched == len(nibbles) && !t.isTombstone(leaf.Value) {
+				return nil, false
+			}
+			return proof, true
+		}
+
+		if branch, ok := node.(*BranchNode); ok {
+			if len(nibbles) == 0 {
+				if branch.HasValue() && !t.isTombstone(branch.Value) {
+					return nil, false
+				}
+				return proof, true
+			}
+
+			b, remaining := nibbles[0], nibbles[1:]
+			nibbles = remaining
+			node = branch.Branches[b]
+			continue
+		}
+
+		if ext, ok := node.(*ExtensionNode); ok {
+			matched := PrefixMatchedLen(ext.Path, nibbles)
+			if matched < len(ext.Path) {
+				return proof, true
+			}
+
+			nibbles = nibbles[matched:]
+			node = ext.Next
+			continue
+		}
+
+		panic("unknown type")
+	}
+}
+
+// VerifyAbsenceProof verifies the proof returned by ProveAbsence for the given
+// key under the given root hash.
+// It returns nil if the proof shows the key is not in the trie, otherwise error
+// will be returned. A key holding Tombstone exists for this check, use
+// VerifyTombstoneProof for a trie in tombstone mode.
+func VerifyAbsenceProof(rootHash []byte, key []byte, proof Proof) error {
+	return verifyAbsenceProof(rootHash, key, proof, false)
+}
+
+// VerifyTombstoneProof is like VerifyAbsenceProof, for a trie in tombstone
+// mode: it also accepts a proof that the key's value is Tombstone.
+func VerifyTombstoneProof(rootHash []byte, key []byte, proof Proof) error {
+	return verifyAbsenceProof(rootHash, key, proof, true)
+}
+
+func verifyAbsenceProof(rootHash []byte, key []byte, proof Proof, tombstones bool) error {
+	// nothing is in an empty trie, there is no node to prove it
+	if bytes.Equal(rootHash, EmptyNodeHash) {
+		return nil
+	}
+
+	value, err := VerifyProof(rootHash, key, proof)
+	if err != nil {
+		return err
+	}
+	if value == nil || (tombstones && bytes.Equal(value, Tombstone)) {
+		return nil
+	}
+	return fmt.Errorf("key %x exists in the trie", key)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProveAbsence(t *testing.T) {
+	tr := NewTrie()
+	tr.Put([]byte{1, 2, 3}, []byte("hello"))
+	tr.Put([]byte{1, 2, 3, 4, 5}, []byte("world"))
+	tr.Put([]byte{1, 2, 5}, []byte("trie"))
+
+	t.Run("should prove the absence of keys diverging at each kind of node", func(t *testing.T) {
+		absentKeys := [][]byte{
+			{2},             // diverges from the extension node
+			{1, 2},          // ends inside the extension node
+			{1, 2, 3, 4},    // ends inside a leaf node's path
+			{1, 2, 3, 4, 6}, // diverges from a leaf node's path
+			{1, 2, 4},       // reaches an empty branch
+		}
+		for _, key := range absentKeys {
+			proof, ok := tr.ProveAbsence(key)
+			require.True(t, ok, "%x", key)
+			require.NoError(t, VerifyAbsenceProof(tr.Hash(), key, proof), "%x", key)
+		}
+	})
+
+	t.Run("should not prove the absence of an existing key", func(t *testing.T) {
+		proof, ok := tr.ProveAbsence([]byte{1, 2, 3})
+		require.False(t, ok)
+		require.Nil(t, proof)
+
+		// the value is held by a branch node
+		proof, ok = tr.ProveAbsence([]byte{1, 2, 3, 4, 5})
+		require.False(t, ok)
+		require.Nil(t, proof)
+
+		proof, ok = tr.Prove([]byte{1, 2, 3})
+		require.True(t, ok)
+		require.Error(t, VerifyAbsenceProof(tr.Hash(), []byte{1, 2, 3}, proof))
+	})
+
+	t.Run("should fail the verification with another root hash", func(t *testing.T) {
+		proof, ok := tr.ProveAbsence([]byte{2})
+		require.True(t, ok)
+
+		other := NewTrie()
+		other.Put([]byte{2}, []byte("other"))
+		require.Error(t, VerifyAbsenceProof(other.Hash(), []byte{2}, proof))
+	})
+
+	t.Run("should prove the absence of any key in an empty trie", func(t *testing.T) {
+		empty := NewTrie()
+		proof, ok := empty.ProveAbsence([]byte{1})
+		require.True(t, ok)
+		require.NoError(t, VerifyAbsenceProof(empty.Hash(), []byte{1}, proof))
+	})
+
+	t.Run("should prove the absence of a key deleted with a tombstone", func(t *testing.T) {
+		tombstoned := NewTrie()
+		tombstoned.EnableTombstones()
+		tombstoned.Put([]byte{1, 2, 3}, []byte("hello"))
+		tombstoned.Put([]byte{1, 2, 3, 4, 5}, []byte("world"))
+		tombstoned.Put([]byte{1, 2, 5}, []byte("trie"))
+
+		for _, key := range [][]byte{{1, 2, 3}, {1, 2, 5}} {
+			_, ok := tombstoned.ProveAbsence(key)
+			require.False(t, ok)
+
+			require.True(t, tombstoned.Delete(key))
+			proof, ok := tombstoned.ProveAbsence(key)
+			require.True(t, ok, "%x", key)
+			require.NoError(t, VerifyTombstoneProof(tombstoned.Hash(), key, proof), "%x", key)
+			require.Error(t, VerifyAbsenceProof(tombstoned.Hash(), key, proof), "%x", key)
+		}
+
+		proof, ok := tombstoned.Prove([]byte{1, 2, 3, 4, 5})
+		require.True(t, ok)
+		require.Error(t, VerifyTombstoneProof(tombstoned.Hash(), []byte{1, 2, 3, 4, 5}, proof))
+	})
+
+	t.Run("should not prove the absence of a key holding Tombstone in a plain trie", func(t *testing.T) {
+		plain := NewTrie()
+		plain.Put([]byte{1, 2, 3}, Tombstone)
+
+		proof, ok := plain.Prove([]byte{1, 2, 3})
+		require.True(t, ok)
+		require.Error(t, VerifyAbsenceProof(plain.Hash(), []byte{1, 2, 3}, proof))
+	})
+}
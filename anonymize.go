@@ -0,0 +1,69 @@
+package main
+
+// Anonymize returns a copy of the trie that can be shared to reproduce
+// structural issues without leaking its data: values are replaced by their
+// hash, and keys by deterministic pseudonyms derived from the seed.
+// The pseudonyms preserve the shared prefixes between keys, so the copy has
+// the same branches, extensions and leaves as the trie, under its own root hash.
+// As every value becomes 32 bytes long, nodes small enough to be embedded in
+// their parent in the trie may be referenced by their hash in the copy, so
+// the copy can have more hashed nodes, and a proof of a key a different size.
+// Nodes only known by their hash, in a trie built from a proof, are kept as is.
+func (t *Trie) Anonymize(seed []byte) *Trie {
+	anonymized := NewTrie()
+	anonymized.root = anonymizeNode(t.root, []Nibble{}, []Nibble{}, seed)
+	return anonymized
+}
+
+// anonymizeNode returns the anonymized copy of the node at the given path,
+// whose pseudonym is given.
+func anonymizeNode(node Node, path []Nibble, pseudonym []Nibble, seed []byte) Node {
+	if IsEmptyNode(node) {
+		return nil
+	}
+
+	if leaf, ok := node.(*LeafNode); ok {
+		_, leafPseudonym := extendPseudonym(path, pseudonym, leaf.Path, seed)
+		return NewLeafNodeFromNibbles(leafPseudonym[len(pseudonym):], Keccak256(leaf.Value))
+	}
+
+	if branch, ok := node.(*BranchNode); ok {
+		anonymized := NewBranchNode()
+		if branch.HasValue() {
+			anonymized.SetValue(Keccak256(branch.Value))
+		}
+		for i, child := range branch.Branches {
+			if IsEmptyNode(child) {
+				continue
+			}
+			childPath, childPseudonym := extendPseudonym(path, pseudonym, []Nibble{Nibble(i)}, seed)
+			anonymized.SetBranch(childPseudonym[len(pseudonym)], anonymizeNode(child, childPath, childPseudonym, seed))
+		}
+		return anonymized
+	}
+
+	if ext, ok := node.(*ExtensionNode); ok {
+		nextPath, nextPseudonym := extendPseudonym(path, pseudonym, ext.Path, seed)
+		return NewExtensionNode(nextPseudonym[len(pseudonym):], anonymizeNode(ext.Next, nextPath, nextPseudonym, seed))
+	}
+
+	if hash, ok := node.(HashNode); ok {
+		// the content of a hash node is unknown, there is nothing to anonymize
+		return hash
+	}
+
+	panic("unknown type")
+}
+
+// extendPseudonym appends the nibbles to the path, and their pseudonyms to the
+// pseudonym of the path. Each nibble is mixed with a mask derived from the seed
+// and the nibbles before it, so that keys sharing a prefix have pseudonyms
+// sharing a prefix of the same length.
+func extendPseudonym(path []Nibble, pseudonym []Nibble, nibbles []Nibble, seed []byte) ([]Nibble, []Nibble) {
+	for _, nibble := range nibbles {
+		mask := Keccak256(seed, ToBytes(ToPrefixed(path, false)))[0] & 0x0f
+		pseudonym = appendNibbles(pseudonym, nibble^Nibble(mask))
+		path = appendNibbles(path, nibble)
+	}
+	return path, pseudonym
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// shape describes the types and path lengths of the nodes under the given
+// node, ignoring the order of the children of branch nodes.
+func shape(node Node) string {
+	if IsEmptyNode(node) {
+		return "_"
+	}
+
+	switch n := node.(type) {
+	case *LeafNode:
+		return fmt.Sprintf("L%v", len(n.Path))
+	case *ExtensionNode:
+		return fmt.Sprintf("E%v(%v)", len(n.Path), shape(n.Next))
+	case HashNode:
+		return "H"
+	case *BranchNode:
+		children := make([]string, 0)
+		for _, child := range n.Branches {
+			if !IsEmptyNode(child) {
+				children = append(children, shape(child))
+			}
+		}
+		sort.Strings(children)
+		return fmt.Sprintf("B%v%v", n.HasValue(), children)
+	}
+
+	panic("unknown type")
+}
+
+func TestAnonymize(t *testing.T) {
+	tr := NewTrie()
+	for i := 0; i < 50; i++ {
+		tr.Put([]byte(fmt.Sprintf("key%v", i)), []byte(fmt.Sprintf("secret%v", i)))
+	}
+	tr.Put([]byte("key"), []byte("secret"))
+
+	anonymized := tr.Anonymize([]byte("seed"))
+
+	t.Run("should keep the shape of the trie", func(t *testing.T) {
+		require.Equal(t, shape(tr.root), shape(anonymized.root))
+	})
+
+	t.Run("should not contain the keys or the values", func(t *testing.T) {
+		_, found := anonymized.Get([]byte("key7"))
+		require.False(t, found)
+		require.NotEqual(t, tr.Hash(), anonymized.Hash())
+	})
+
+	t.Run("should be deterministic for a seed", func(t *testing.T) {
+		require.Equal(t, anonymized.Hash(), tr.Anonymize([]byte("seed")).Hash())
+		require.NotEqual(t, anonymized.Hash(), tr.Anonymize([]byte("other seed")).Hash())
+	})
+
+	t.Run("should keep the hash nodes of a partial trie", func(t *testing.T) {
+		proof, ok := tr.Prove([]byte("key7"))
+		require.True(t, ok)
+		partial, err := NewTrieFromProof(tr.Hash(), proof)
+		require.NoError(t, err)
+
+		anonymizedPartial := partial.Anonymize([]byte("seed"))
+		require.Equal(t, shape(partial.root), shape(anonymizedPartial.root))
+		require.NotEqual(t, partial.Hash(), anonymizedPartial.Hash())
+	})
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// AuditRecord describes a single mutation of the trie, and the root hash the
+// trie had right after it.
+// For a deletion, Deleted is true and ValueHash is empty. When Compact removes
+// a key deleted in tombstone mode, Compacted is true as well.
+type AuditRecord struct {
+	KeyHash   hexutil.Bytes `json:"keyHash"`
+	ValueHash hexutil.Bytes `json:"valueHash,omitempty"`
+	Deleted   bool          `json:"deleted,omitempty"`
+	Compacted bool          `json:"compacted,omitempty"`
+	Root      hexutil.Bytes `json:"root"`
+	Timestamp time.Time     `json:"timestamp"`
+}
+
+// SetAuditWriter makes the trie append an AuditRecord, encoded as one JSON
+// object per line, to w after every mutation. Passing nil turns it off.
+func (t *Trie) SetAuditWriter(w io.Writer) {
+	if w == nil {
+		t.audit = nil
+		return
+	}
+	t.audit = json.NewEncoder(w)
+	t.auditErr = nil
+}
+
+// AuditErr returns the first error the audit writer failed with, if any.
+// Once it fails, no more records are written.
+func (t *Trie) AuditErr() error {
+	return t.auditErr
+}
+
+func (t *Trie) writeAudit(key []byte, value []byte) {
+	t.writeAuditRecord(AuditRecord{
+		KeyHash:   Keccak256(key),
+		ValueHash: Keccak256(value),
+	})
+}
+
+func (t *Trie) writeAuditDelete(key []byte) {
+	t.writeAuditRecord(AuditRecord{
+		KeyHash: Keccak256(key),
+		Deleted: true,
+	})
+}
+
+func (t *Trie) writeAuditCompact(key []byte) {
+	t.writeAuditRecord(AuditRecord{
+		KeyHash:   Keccak256(key),
+		Deleted:   true,
+		Compacted: true,
+	})
+}
+
+func (t *Trie) writeAuditRecord(record AuditRecord) {
+	if t.audit == nil || t.auditErr != nil {
+		return
+	}
+
+	record.Root = t.Hash()
+	record.Timestamp = time.Now().UTC()
+	err := t.audit.Encode(record)
+	if err != nil {
+		t.auditErr = fmt.Errorf("could not write audit record: %w", err)
+	}
+}
+
+// ReadAuditRecords decodes all the records written by an audit writer.
+func ReadAuditRecords(r io.Reader) ([]AuditRecord, error) {
+	records := make([]AuditRecord, 0)
+	decoder := json.NewDecoder(r)
+	for {
+		var record AuditRecord
+		err := decoder.Decode(&record)
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read audit record %v: %w", len(records), err)
+		}
+		records = append(records, record)
+	}
+}
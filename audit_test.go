@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestAuditWriter(t *testing.T) {
+	t.Run("should record every mutation with the resulting root", func(t *testing.T) {
+		var buf bytes.Buffer
+		tr := NewTrie()
+		tr.SetAuditWriter(&buf)
+
+		tr.Put([]byte{1, 2, 3, 4}, []byte("hello"))
+		root1 := tr.Hash()
+		tr.Put([]byte{1, 2}, []byte("world"))
+		root2 := tr.Hash()
+
+		records, err := ReadAuditRecords(&buf)
+		require.NoError(t, err)
+		require.Len(t, records, 2)
+
+		require.Equal(t, Keccak256([]byte{1, 2, 3, 4}), []byte(records[0].KeyHash))
+		require.Equal(t, Keccak256([]byte("hello")), []byte(records[0].ValueHash))
+		require.Equal(t, root1, []byte(records[0].Root))
+		require.Equal(t, root2, []byte(records[1].Root))
+		require.False(t, records[1].Timestamp.Before(records[0].Timestamp))
+	})
+
+	t.Run("should record deletions", func(t *testing.T) {
+		var buf bytes.Buffer
+		tr := NewTrie()
+		tr.Put([]byte{1}, []byte("hello"))
+		tr.SetAuditWriter(&buf)
+		tr.Delete([]byte{1})
+		tr.Delete([]byte{2})
+
+		records, err := ReadAuditRecords(&buf)
+		require.NoError(t, err)
+		require.Len(t, records, 1)
+		require.True(t, records[0].Deleted)
+		require.Empty(t, records[0].ValueHash)
+		require.Equal(t, EmptyNodeHash, []byte(records[0].Root))
+	})
+
+	t.Run("should stop auditing when the writer is removed", func(t *testing.T) {
+		var buf bytes.Buffer
+		tr := NewTrie()
+		tr.SetAuditWriter(&buf)
+		tr.Put([]byte{1}, []byte("hello"))
+		tr.SetAuditWriter(nil)
+		tr.Put([]byte{2}, []byte("world"))
+
+		records, err := ReadAuditRecords(&buf)
+		require.NoError(t, err)
+		require.Len(t, records, 1)
+	})
+
+	t.Run("should report the writer's error", func(t *testing.T) {
+		tr := NewTrie()
+		tr.SetAuditWriter(failingWriter{})
+		tr.Put([]byte{1}, []byte("hello"))
+		require.Error(t, tr.AuditErr())
+
+		_, found := tr.Get([]byte{1})
+		require.True(t, found)
+	})
+}
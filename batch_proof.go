@@ -0,0 +1,69 @@
+package main
+
+// provedNode is a proof node with its hash, computed once per batch.
+type provedNode struct {
+	hash       []byte
+	serialized []byte
+}
+
+// proofBatch hashes and serializes the nodes of the proofs of a batch of keys,
+// so that the nodes shared by several paths, the root at least, are only
+// encoded once.
+type proofBatch struct {
+	trie  *Trie
+	nodes map[Node]provedNode
+}
+
+func (t *Trie) newProofBatch() *proofBatch {
+	return &proofBatch{
+		trie:  t,
+		nodes: make(map[Node]provedNode),
+	}
+}
+
+// prove puts the proof nodes of the key into the proof, and returns whether
+// the key was found, in which case the proof is the one Prove returns.
+func (b *proofBatch) prove(key []byte, proof Proof) bool {
+	nodes, found := b.trie.pathNodes(key)
+	if !found {
+		return false
+	}
+
+	for _, node := range nodes {
+		proved, ok := b.nodes[node]
+		if !ok {
+			proved = provedNode{hash: Hash(node), serialized: Serialize(node)}
+			b.nodes[node] = proved
+		}
+		proof.Put(proved.hash, proved.serialized)
+	}
+	return true
+}
+
+// ProveBatch returns the proofs for the given keys, in the same order, with a
+// nil proof for the keys that don't exist. It is faster than calling Prove for
+// each key, since the nodes shared by the paths are encoded once.
+func (t *Trie) ProveBatch(keys [][]byte) []Proof {
+	batch := t.newProofBatch()
+	proofs := make([]Proof, len(keys))
+	for i, key := range keys {
+		proof := NewProofDB()
+		if batch.prove(key, proof) {
+			proofs[i] = proof
+		}
+	}
+	return proofs
+}
+
+// ProveBatchShared returns a single proof containing the proof nodes of all
+// the given keys, which each key can be verified with, and whether each key
+// was found. Keys that don't exist add no nodes to the proof.
+func (t *Trie) ProveBatchShared(keys [][]byte) (Proof, []bool) {
+	batch := t.newProofBatch()
+	proof := NewProofDB()
+	found := make([]bool, len(keys))
+	for i, key := range keys {
+		found[i] = batch.prove(key, proof)
+	}
+	return proof, found
+}
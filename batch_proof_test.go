@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProveBatch(t *testing.T) {
+	tr := newTestTrie(t, 100)
+	keys := append(testKeys(100), []byte("notexist"))
+
+	t.Run("should return the same proofs as Prove", func(t *testing.T) {
+		proofs := tr.ProveBatch(keys)
+		require.Len(t, proofs, len(keys))
+		for i, key := range keys {
+			proof, ok := tr.Prove(key)
+			if !ok {
+				require.Nil(t, proofs[i])
+				continue
+			}
+			require.ElementsMatch(t, proof.Serialize(), proofs[i].Serialize())
+		}
+	})
+
+	t.Run("should verify all keys with the shared proof", func(t *testing.T) {
+		proof, found := tr.ProveBatchShared(keys)
+		for i := range keys {
+			require.Equal(t, i < len(keys)-1, found[i])
+		}
+
+		for i, key := range keys[:len(keys)-1] {
+			val, err := VerifyProof(tr.Hash(), key, proof)
+			require.NoError(t, err)
+			require.Equal(t, testValue(i), val)
+		}
+	})
+}
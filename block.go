@@ -0,0 +1,300 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// RPCBlock is a block returned by eth_getBlockByNumber with full transactions,
+// only the fields needed to rebuild its transactions trie are decoded.
+type RPCBlock struct {
+	TransactionsRoot common.Hash      `json:"transactionsRoot"`
+	Transactions     []RPCTransaction `json:"transactions"`
+}
+
+// RPCTransaction is a transaction as returned by eth_getBlockByNumber, which is
+// either a legacy transaction or a typed transaction (EIP-2718):
+// an access list transaction (EIP-2930) or a dynamic fee transaction (EIP-1559).
+type RPCTransaction struct {
+	Type                 *hexutil.Uint64 `json:"type"`
+	ChainID              *hexutil.Big    `json:"chainId"`
+	Nonce                hexutil.Uint64  `json:"nonce"`
+	GasPrice             *hexutil.Big    `json:"gasPrice"`
+	MaxPriorityFeePerGas *hexutil.Big    `json:"maxPriorityFeePerGas"`
+	MaxFeePerGas         *hexutil.Big    `json:"maxFeePerGas"`
+	Gas                  hexutil.Uint64  `json:"gas"`
+	To                   *common.Address `json:"to"`
+	Value                *hexutil.Big    `json:"value"`
+	Input                hexutil.Bytes   `json:"input"`
+	AccessList           []AccessTuple   `json:"accessList"`
+	V                    *hexutil.Big    `json:"v"`
+	R                    *hexutil.Big    `json:"r"`
+	S                    *hexutil.Big    `json:"s"`
+	Hash                 common.Hash     `json:"hash"`
+}
+
+type AccessTuple struct {
+	Address     common.Address `json:"address"`
+	StorageKeys []common.Hash  `json:"storageKeys"`
+}
+
+const (
+	LegacyTxType     = 0x00
+	AccessListTxType = 0x01
+	DynamicFeeTxType = 0x02
+)
+
+// Encode returns the encoding of the transaction, which is what the
+// transactions trie stores and what the transaction hash is computed from:
+// the RLP of the fields for legacy transactions, and the type byte followed by
+// the RLP of the fields for typed transactions.
+func (tx RPCTransaction) Encode() ([]byte, error) {
+	txType := uint64(LegacyTxType)
+	if tx.Type != nil {
+		txType = uint64(*tx.Type)
+	}
+
+	switch txType {
+	case LegacyTxType:
+		return Transaction{
+			AccountNonce: uint64(tx.Nonce),
+			Price:        toBig(tx.GasPrice),
+			GasLimit:     uint64(tx.Gas),
+			Recipient:    tx.To,
+			Amount:       toBig(tx.Value),
+			Payload:      tx.Input,
+			V:            toBig(tx.V),
+			R:            toBig(tx.R),
+			S:            toBig(tx.S),
+		}.GetRLP()
+	case AccessListTxType:
+		return encodeTypedTx(AccessListTxType, []interface{}{
+			toBig(tx.ChainID),
+			uint64(tx.Nonce),
+			toBig(tx.GasPrice),
+			uint64(tx.Gas),
+			toBytes(tx.To),
+			toBig(tx.Value),
+			[]byte(tx.Input),
+			accessList(tx.AccessList),
+			toBig(tx.V),
+			toBig(tx.R),
+			toBig(tx.S),
+		})
+	case DynamicFeeTxType:
+		return encodeTypedTx(DynamicFeeTxType, []interface{}{
+			toBig(tx.ChainID),
+			uint64(tx.Nonce),
+			toBig(tx.MaxPriorityFeePerGas),
+			toBig(tx.MaxFeePerGas),
+			uint64(tx.Gas),
+			toBytes(tx.To),
+			toBig(tx.Value),
+			[]byte(tx.Input),
+			accessList(tx.AccessList),
+			toBig(tx.V),
+			toBig(tx.R),
+			toBig(tx.S),
+		})
+	}
+
+	return nil, fmt.Errorf("unsupported transaction type: %v", txType)
+}
+
+// DecodeTransaction decodes a transaction encoded by Encode, as stored in the
+// transactions trie, telling typed transactions apart by their leading byte.
+// Hash is set to the hash of the encoding.
+func DecodeTransaction(encoded []byte) (*RPCTransaction, error) {
+	if len(encoded) == 0 {
+		return nil, fmt.Errorf("empty transaction")
+	}
+
+	var txType hexutil.Uint64
+	var tx RPCTransaction
+	var to []byte
+
+	// the RLP of a legacy transaction is a list, which starts with 0xc0 or more
+	if encoded[0] >= 0xc0 {
+		txType = hexutil.Uint64(LegacyTxType)
+	} else {
+		txType = hexutil.Uint64(encoded[0])
+		if txType == LegacyTxType {
+			return nil, fmt.Errorf("unsupported transaction type: %v", uint64(txType))
+		}
+	}
+
+	switch txType {
+	case LegacyTxType:
+		var fields struct {
+			Nonce    uint64
+			GasPrice *big.Int
+			Gas      uint64
+			To       []byte
+			Value    *big.Int
+			Input    []byte
+			V, R, S  *big.Int
+		}
+		err := rlp.DecodeBytes(encoded, &fields)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode legacy transaction: %w", err)
+		}
+		to = fields.To
+		tx = RPCTransaction{
+			Nonce:    hexutil.Uint64(fields.Nonce),
+			GasPrice: (*hexutil.Big)(fields.GasPrice),
+			Gas:      hexutil.Uint64(fields.Gas),
+			Value:    (*hexutil.Big)(fields.Value),
+			Input:    fields.Input,
+			V:        (*hexutil.Big)(fields.V),
+			R:        (*hexutil.Big)(fields.R),
+			S:        (*hexutil.Big)(fields.S),
+		}
+	case AccessListTxType:
+		var fields struct {
+			ChainID    *big.Int
+			Nonce      uint64
+			GasPrice   *big.Int
+			Gas        uint64
+			To         []byte
+			Value      *big.Int
+			Input      []byte
+			AccessList []AccessTuple
+			V, R, S    *big.Int
+		}
+		err := rlp.DecodeBytes(encoded[1:], &fields)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode access list transaction: %w", err)
+		}
+		to = fields.To
+		tx = RPCTransaction{
+			ChainID:    (*hexutil.Big)(fields.ChainID),
+			Nonce:      hexutil.Uint64(fields.Nonce),
+			GasPrice:   (*hexutil.Big)(fields.GasPrice),
+			Gas:        hexutil.Uint64(fields.Gas),
+			Value:      (*hexutil.Big)(fields.Value),
+			Input:      fields.Input,
+			AccessList: fields.AccessList,
+			V:          (*hexutil.Big)(fields.V),
+			R:          (*hexutil.Big)(fields.R),
+			S:          (*hexutil.Big)(fields.S),
+		}
+	case DynamicFeeTxType:
+		var fields struct {
+			ChainID              *big.Int
+			Nonce                uint64
+			MaxPriorityFeePerGas *big.Int
+			MaxFeePerGas         *big.Int
+			Gas                  uint64
+			To                   []byte
+			Value                *big.Int
+			Input                []byte
+			AccessList           []AccessTuple
+			V, R, S              *big.Int
+		}
+		err := rlp.DecodeBytes(encoded[1:], &fields)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode dynamic fee transaction: %w", err)
+		}
+		to = fields.To
+		tx = RPCTransaction{
+			ChainID:              (*hexutil.Big)(fields.ChainID),
+			Nonce:                hexutil.Uint64(fields.Nonce),
+			MaxPriorityFeePerGas: (*hexutil.Big)(fields.MaxPriorityFeePerGas),
+			MaxFeePerGas:         (*hexutil.Big)(fields.MaxFeePerGas),
+			Gas:                  hexutil.Uint64(fields.Gas),
+			Value:                (*hexutil.Big)(fields.Value),
+			Input:                fields.Input,
+			AccessList:           fields.AccessList,
+			V:                    (*hexutil.Big)(fields.V),
+			R:                    (*hexutil.Big)(fields.R),
+			S:                    (*hexutil.Big)(fields.S),
+		}
+	default:
+		return nil, fmt.Errorf("unsupported transaction type: %v", uint64(txType))
+	}
+
+	// the recipient is empty for contract creations
+	switch len(to) {
+	case 0:
+	case common.AddressLength:
+		address := common.BytesToAddress(to)
+		tx.To = &address
+	default:
+		return nil, fmt.Errorf("invalid recipient: %x", to)
+	}
+
+	tx.Type = &txType
+	tx.Hash = common.BytesToHash(Keccak256(encoded))
+	return &tx, nil
+}
+
+func encodeTypedTx(txType byte, fields []interface{}) ([]byte, error) {
+	payload, err := rlp.EncodeToBytes(fields)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{txType}, payload...), nil
+}
+
+func accessList(tuples []AccessTuple) []interface{} {
+	list := make([]interface{}, 0, len(tuples))
+	for _, tuple := range tuples {
+		keys := make([]common.Hash, 0, len(tuple.StorageKeys))
+		keys = append(keys, tuple.StorageKeys...)
+		list = append(list, []interface{}{tuple.Address, keys})
+	}
+	return list
+}
+
+func toBig(n *hexutil.Big) *big.Int {
+	if n == nil {
+		return new(big.Int)
+	}
+	return n.ToInt()
+}
+
+// toBytes returns the recipient address, which is empty for contract creations.
+func toBytes(address *common.Address) []byte {
+	if address == nil {
+		return []byte{}
+	}
+	return address.Bytes()
+}
+
+// TransactionsTrieFromBlockJSON builds the transactions trie of a block from the
+// JSON of the block returned by eth_getBlockByNumber with full transactions.
+// It returns an error if the hash of a transaction or the root of the trie
+// doesn't match the ones in the JSON.
+func TransactionsTrieFromBlockJSON(data []byte) (*Trie, error) {
+	var block RPCBlock
+	err := json.Unmarshal(data, &block)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode block: %w", err)
+	}
+
+	trie := NewTrie()
+	for i, tx := range block.Transactions {
+		encoded, err := tx.Encode()
+		if err != nil {
+			return nil, fmt.Errorf("could not encode transaction %v: %w", i, err)
+		}
+
+		if (tx.Hash != common.Hash{}) && !bytes.Equal(Keccak256(encoded), tx.Hash.Bytes()) {
+			return nil, fmt.Errorf("transaction %v does not match its hash %x", i, tx.Hash)
+		}
+
+		trie.Put(LogKey(i), encoded)
+	}
+
+	if !bytes.Equal(trie.Hash(), block.TransactionsRoot.Bytes()) {
+		return nil, fmt.Errorf("transactions root mismatch, expected %x, got %x", block.TransactionsRoot, trie.Hash())
+	}
+
+	return trie, nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/stretchr/testify/require"
+)
+
+// blockJSON returns the JSON of block 10467135 with its transactions
+func blockJSON(t *testing.T, transactionsRoot string) []byte {
+	txs, err := ioutil.ReadFile("transactions.json")
+	require.NoError(t, err)
+
+	block, err := json.Marshal(map[string]interface{}{
+		"transactionsRoot": transactionsRoot,
+		"transactions":     json.RawMessage(txs),
+	})
+	require.NoError(t, err)
+	return block
+}
+
+func TestTransactionsTrieFromBlockJSON(t *testing.T) {
+	t.Run("should rebuild the transactions trie of block 10467135", func(t *testing.T) {
+		trie, err := TransactionsTrieFromBlockJSON(
+			blockJSON(t, "0xbb345e208bda953c908027a45aa443d6cab6b8d2fd64e83ec52f1008ddeafa58"))
+		require.NoError(t, err)
+
+		txs := TransactionsJSON(t)
+		value, found := trie.Get(LogKey(30))
+		require.True(t, found)
+		rlp, err := FromEthTransaction(txs[30]).GetRLP()
+		require.NoError(t, err)
+		require.Equal(t, rlp, value)
+	})
+
+	t.Run("should fail if the transactions root doesn't match", func(t *testing.T) {
+		_, err := TransactionsTrieFromBlockJSON(blockJSON(t, common.BytesToHash(EmptyNodeHash).Hex()))
+		require.Error(t, err)
+	})
+}
+
+func TestTypedTransactionsTrieFromBlockJSON(t *testing.T) {
+	// the transactions of the blocks of go-ethereum's EIP-2718 and EIP-1559
+	// block encoding tests, with their hashes and transactions roots computed
+	// by go-ethereum v1.10.13
+	data, err := ioutil.ReadFile("typed_blocks.json")
+	require.NoError(t, err)
+	var blocks []json.RawMessage
+	require.NoError(t, json.Unmarshal(data, &blocks))
+	require.Len(t, blocks, 2)
+
+	for _, block := range blocks {
+		// the hash of each transaction is checked against its encoding
+		trie, err := TransactionsTrieFromBlockJSON(block)
+		require.NoError(t, err)
+
+		encoded, found := trie.Get(LogKey(1))
+		require.True(t, found)
+		tx, err := DecodeTransaction(encoded)
+		require.NoError(t, err)
+		require.NotEqual(t, hexutil.Uint64(LegacyTxType), *tx.Type)
+	}
+}
+
+func TestTypedTransactionEncoding(t *testing.T) {
+	var tx RPCTransaction
+	err := json.Unmarshal([]byte(`{
+		"type": "0x2",
+		"chainId": "0x1",
+		"nonce": "0x5",
+		"maxPriorityFeePerGas": "0x3b9aca00",
+		"maxFeePerGas": "0x6fc23ac00",
+		"gas": "0x5208",
+		"to": null,
+		"value": "0x1",
+		"input": "0x6000",
+		"accessList": [{
+			"address": "0x897c3dec007e1bcd7b8dcc1f304c2246eea68537",
+			"storageKeys": ["0x0000000000000000000000000000000000000000000000000000000000000001"]
+		}],
+		"v": "0x1",
+		"r": "0x2",
+		"s": "0x3"
+	}`), &tx)
+	require.NoError(t, err)
+
+	encoded, err := tx.Encode()
+	require.NoError(t, err)
+	require.Equal(t, byte(DynamicFeeTxType), encoded[0])
+
+	var fields []rlp.RawValue
+	require.NoError(t, rlp.DecodeBytes(encoded[1:], &fields))
+	require.Len(t, fields, 12)
+
+	var to []byte
+	require.NoError(t, rlp.DecodeBytes(fields[5], &to))
+	require.Empty(t, to)
+
+	var list []struct {
+		Address     common.Address
+		StorageKeys []common.Hash
+	}
+	require.NoError(t, rlp.DecodeBytes(fields[8], &list))
+	require.Equal(t, common.HexToAddress("0x897c3dec007e1bcd7b8dcc1f304c2246eea68537"), list[0].Address)
+	require.Equal(t, []common.Hash{common.BigToHash(common.Big1)}, list[0].StorageKeys)
+
+	t.Run("should reject unknown types", func(t *testing.T) {
+		unknown := hexutil.Uint64(0x7f)
+		tx.Type = &unknown
+		_, err := tx.Encode()
+		require.Error(t, err)
+	})
+}
@@ -41,17 +41,7 @@ func (b BranchNode) Raw() []interface{} {
 		if b.Branches[i] == nil {
 			hashes[i] = EmptyNodeRaw
 		} else {
-			node := b.Branches[i]
-			if len(Serialize(node)) >= 32 {
-				hashes[i] = node.Hash()
-			} else {
-				// if node can be serialized to less than 32 bits, then
-				// use Serialized directly.
-				// it has to be ">=", rather than ">",
-				// so that when deserialized, the content can be distinguished
-				// by length
-				hashes[i] = node.Raw()
-			}
+			hashes[i] = RawRef(b.Branches[i])
 		}
 	}
 
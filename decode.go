@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// HashNode is a reference to a node by its hash. The children of a decoded
+// node are HashNodes unless they were small enough to be embedded in it.
+type HashNode []byte
+
+func (h HashNode) Hash() []byte {
+	return h
+}
+
+// Raw panics, since the content of the node is unknown. Parent nodes reference
+// a HashNode by its hash, see RawRef.
+func (h HashNode) Raw() []interface{} {
+	panic("raw form of a hash node is unknown")
+}
+
+// DecodeNode decodes a serialized node, which is the reverse of Serialize.
+func DecodeNode(data []byte) (Node, error) {
+	var items []rlp.RawValue
+	err := rlp.DecodeBytes(data, &items)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode node: %w", err)
+	}
+
+	switch len(items) {
+	case 2:
+		return decodeShortNode(items)
+	case 17:
+		return decodeBranchNode(items)
+	}
+
+	return nil, fmt.Errorf("invalid number of items in node: %v", len(items))
+}
+
+func decodeShortNode(items []rlp.RawValue) (Node, error) {
+	var path []byte
+	err := rlp.DecodeBytes(items[0], &path)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode path: %w", err)
+	}
+
+	nibbles, isLeafNode, err := FromPrefixed(FromBytes(path))
+	if err != nil {
+		return nil, fmt.Errorf("could not decode path %x: %w", path, err)
+	}
+
+	if isLeafNode {
+		var value []byte
+		err := rlp.DecodeBytes(items[1], &value)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode leaf value: %w", err)
+		}
+		return NewLeafNodeFromNibbles(nibbles, value), nil
+	}
+
+	next, err := decodeRef(items[1])
+	if err != nil {
+		return nil, fmt.Errorf("could not decode extension node: %w", err)
+	}
+	if IsEmptyNode(next) {
+		return nil, fmt.Errorf("extension node has no next node")
+	}
+	return NewExtensionNode(nibbles, next), nil
+}
+
+func decodeBranchNode(items []rlp.RawValue) (Node, error) {
+	branch := NewBranchNode()
+	for i := 0; i < 16; i++ {
+		node, err := decodeRef(items[i])
+		if err != nil {
+			return nil, fmt.Errorf("could not decode branch %v: %w", i, err)
+		}
+		branch.SetBranch(Nibble(i), node)
+	}
+
+	var value []byte
+	err := rlp.DecodeBytes(items[16], &value)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode branch value: %w", err)
+	}
+	if len(value) > 0 {
+		branch.SetValue(value)
+	}
+	return branch, nil
+}
+
+// decodeRef decodes the reference to a child node, see RawRef.
+func decodeRef(raw rlp.RawValue) (Node, error) {
+	kind, content, _, err := rlp.Split(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	if kind == rlp.List {
+		return DecodeNode(raw)
+	}
+
+	switch len(content) {
+	case 0:
+		return nil, nil
+	case 32:
+		return HashNode(content), nil
+	}
+
+	return nil, fmt.Errorf("invalid node reference: %x", content)
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeNode(t *testing.T) {
+	tr := newTestTrie(t, 100)
+	tr.Put([]byte("key"), []byte("value"))
+
+	var check func(node Node)
+	check = func(node Node) {
+		decoded, err := DecodeNode(Serialize(node))
+		require.NoError(t, err)
+		require.Equal(t, Serialize(node), Serialize(decoded))
+		require.Equal(t, node.Hash(), decoded.Hash())
+
+		switch n := node.(type) {
+		case *BranchNode:
+			for _, child := range n.Branches {
+				if child != nil {
+					check(child)
+				}
+			}
+		case *ExtensionNode:
+			check(n.Next)
+		}
+	}
+	check(tr.root)
+
+	_, err := DecodeNode([]byte{0xc0})
+	require.Error(t, err)
+}
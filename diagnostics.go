@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// recentOperations is the number of operations kept for the diagnostics.
+const recentOperations = 32
+
+// Operation is an operation called on the trie.
+type Operation struct {
+	Name string        `json:"name"`
+	Key  hexutil.Bytes `json:"key"`
+}
+
+// Diagnostics describes the state of the trie when one of its operations
+// panicked, see SetPanicHook.
+type Diagnostics struct {
+	Panic     string    `json:"panic"`
+	Operation Operation `json:"operation"`
+	// Path is the nibbles of the key walked down from the root before the walk
+	// stopped, at a node only known by its hash for example, in hex
+	Path string `json:"path"`
+	// NodeHashes are the hashes of the nodes on the path, from the root
+	NodeHashes []hexutil.Bytes `json:"nodeHashes"`
+	Mode       []string        `json:"mode"`
+	// RecentOperations are the last operations called, the failing one last
+	RecentOperations []Operation `json:"recentOperations"`
+	Stack            string      `json:"stack"`
+	Timestamp        time.Time   `json:"timestamp"`
+}
+
+// SetPanicHook makes the trie call hook with the Diagnostics of any panic
+// raised by Put, Delete, TryGet or Prove, before panicking again with the same
+// value. Setting a hook also makes the trie remember its recent operations,
+// reads included, which is safe for concurrent reads. SetPanicHook itself must
+// not be called concurrently with other operations.
+// Passing nil turns it off.
+func (t *Trie) SetPanicHook(hook func(*Diagnostics)) {
+	t.panicHook = hook
+	t.operationsMu.Lock()
+	t.operations = nil
+	t.operationsMu.Unlock()
+}
+
+// DiagnosticsFileHook returns a panic hook writing the Diagnostics as JSON to a
+// new file in the given directory.
+func DiagnosticsFileHook(dir string) func(*Diagnostics) {
+	return func(d *Diagnostics) {
+		data, err := json.MarshalIndent(d, "", "  ")
+		if err != nil {
+			return
+		}
+		name := fmt.Sprintf("mpt-diagnostics-%v.json", d.Timestamp.UnixNano())
+		// the trie is already failing, there is nothing more to do with the error
+		_ = os.WriteFile(filepath.Join(dir, name), data, 0644)
+	}
+}
+
+// recordOperation adds the operation to the recent operations, and returns it.
+func (t *Trie) recordOperation(name string, key []byte) Operation {
+	op := Operation{
+		Name: name,
+		Key:  append([]byte{}, key...),
+	}
+
+	t.operationsMu.Lock()
+	defer t.operationsMu.Unlock()
+	t.operations = append(t.operations, op)
+	if len(t.operations) > recentOperations {
+		t.operations = t.operations[len(t.operations)-recentOperations:]
+	}
+	return op
+}
+
+// recoverPanic must be deferred with the operation returned by recordOperation,
+// it reports the panic of the operation to the panic hook.
+func (t *Trie) recoverPanic(op Operation) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	t.operationsMu.Lock()
+	recent := append([]Operation{}, t.operations...)
+	t.operationsMu.Unlock()
+
+	path := ""
+	for _, n := range t.walkedPath(op.Key) {
+		path += fmt.Sprintf("%x", n)
+	}
+	t.panicHook(&Diagnostics{
+		Panic:            fmt.Sprint(r),
+		Operation:        op,
+		Path:             path,
+		NodeHashes:       t.pathHashes(op.Key),
+		Mode:             t.modes(),
+		RecentOperations: recent,
+		Stack:            string(debug.Stack()),
+		Timestamp:        time.Now().UTC(),
+	})
+	panic(r)
+}
+
+// pathHashes returns the hashes of the nodes on the path to the key, as far as
+// they can be computed from the possibly broken trie.
+func (t *Trie) pathHashes(key []byte) (hashes []hexutil.Bytes) {
+	defer func() {
+		recover()
+	}()
+
+	nodes, _ := t.pathNodes(key)
+	for _, node := range nodes {
+		hashes = append(hashes, node.Hash())
+	}
+	return hashes
+}
+
+// walkedPath returns the nibbles of the key consumed walking down from the root,
+// up to the node where the walk can't go further: a node only known by its
+// hash, an empty node, or a node whose path doesn't match the key.
+func (t *Trie) walkedPath(key []byte) []Nibble {
+	nibbles := FromBytes(key)
+	walked := 0
+	node := t.root
+	for {
+		if branch, ok := node.(*BranchNode); ok && walked < len(nibbles) {
+			node = branch.Branches[nibbles[walked]]
+			walked++
+			continue
+		}
+
+		if ext, ok := node.(*ExtensionNode); ok {
+			matched := PrefixMatchedLen(ext.Path, nibbles[walked:])
+			if matched == len(ext.Path) {
+				node = ext.Next
+				walked += matched
+				continue
+			}
+		}
+
+		return nibbles[:walked]
+	}
+}
+
+func (t *Trie) modes() []string {
+	modes := make([]string, 0)
+	if t.tombstones {
+		modes = append(modes, "tombstones")
+	}
+	if t.values != nil {
+		modes = append(modes, "value commitments")
+	}
+	if t.encodeValue != nil || t.decodeValue != nil {
+		modes = append(modes, "value hooks")
+	}
+	if t.audit != nil {
+		modes = append(modes, "audit")
+	}
+	return modes
+}
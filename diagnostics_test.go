@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPanicHook(t *testing.T) {
+	partial := newPartialTestTrie(t)
+
+	var diagnostics *Diagnostics
+	partial.SetPanicHook(func(d *Diagnostics) {
+		diagnostics = d
+	})
+
+	t.Run("should not call the hook without panic", func(t *testing.T) {
+		partial.Put(testKey(7), []byte("updated"))
+		_, found := partial.Get(testKey(7))
+		require.True(t, found)
+		require.Nil(t, diagnostics)
+	})
+
+	t.Run("should call the hook and panic again", func(t *testing.T) {
+		require.PanicsWithValue(t, ErrNotCovered, func() {
+			partial.Delete([]byte("key30"))
+		})
+		require.NotNil(t, diagnostics)
+		require.Equal(t, ErrNotCovered.Error(), diagnostics.Panic)
+		require.Equal(t, Operation{Name: "delete", Key: []byte("key30")}, diagnostics.Operation)
+		// key30 and key7 diverge at the 8th nibble, key30's branch is a hash node
+		require.Equal(t, "6b657933", diagnostics.Path)
+		require.Equal(t, partial.Hash(), []byte(diagnostics.NodeHashes[0]))
+		require.Equal(t, []Operation{
+			{Name: "put", Key: testKey(7)},
+			{Name: "get", Key: testKey(7)},
+			{Name: "delete", Key: []byte("key30")},
+		}, diagnostics.RecentOperations)
+		require.Contains(t, diagnostics.Stack, "Delete")
+	})
+
+	t.Run("should write the diagnostics to a file", func(t *testing.T) {
+		dir := t.TempDir()
+		partial.SetPanicHook(DiagnosticsFileHook(dir))
+		require.Panics(t, func() {
+			partial.Put([]byte("key30"), []byte("value"))
+		})
+
+		files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+		require.NoError(t, err)
+		require.Len(t, files, 1)
+		data, err := os.ReadFile(files[0])
+		require.NoError(t, err)
+		var written Diagnostics
+		require.NoError(t, json.Unmarshal(data, &written))
+		require.Equal(t, Operation{Name: "put", Key: []byte("key30")}, written.Operation)
+	})
+
+	t.Run("should record concurrent reads", func(t *testing.T) {
+		partial.SetPanicHook(func(d *Diagnostics) {})
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					partial.Get(testKey(7))
+					partial.Prove(testKey(7))
+				}
+			}()
+		}
+		wg.Wait()
+		require.Len(t, partial.operations, recentOperations)
+	})
+}
@@ -23,7 +23,7 @@ func TestERC20(t *testing.T) {
 	require.NoError(t, err)
 	fmt.Println(fmt.Sprintf("slot index %v", slotIndex))
 
-	err = VerifyStorageProof(result)
+	err = VerifyStorageStateResult(result)
 	require.NoError(t, err)
 
 	// convert hex to bigInt
@@ -32,7 +32,7 @@ func TestERC20(t *testing.T) {
 	fmt.Println(fmt.Sprintf("the balance of token holder %x for contract %x's %v", tokenHolder, erc20Address, balance))
 }
 
-func VerifyStorageProof(result *StorageStateResult) error {
+func VerifyStorageStateResult(result *StorageStateResult) error {
 	storageHash := result.StorageHash
 	storageProof := result.StorageProof[0]
 	value, err := rlp.EncodeToBytes(storageProof.Value)
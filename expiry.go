@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"sort"
+)
+
+// ExpiryIndex keeps an expiry height for keys of a trie, next to the trie,
+// so that the expired keys can be swept from the trie in one operation.
+// The index is not part of the trie, and doesn't change its hash.
+type ExpiryIndex struct {
+	trie   *Trie
+	expiry map[string]uint64
+}
+
+func NewExpiryIndex(trie *Trie) *ExpiryIndex {
+	return &ExpiryIndex{
+		trie:   trie,
+		expiry: make(map[string]uint64),
+	}
+}
+
+// Put adds the key value pair to the trie, expiring at the given height.
+func (e *ExpiryIndex) Put(key []byte, value []byte, expiry uint64) {
+	e.trie.Put(key, value)
+	e.expiry[string(key)] = expiry
+}
+
+// Delete removes the key from the trie and from the index.
+func (e *ExpiryIndex) Delete(key []byte) bool {
+	delete(e.expiry, string(key))
+	return e.trie.Delete(key)
+}
+
+// Expiry returns the height at which the key expires.
+func (e *ExpiryIndex) Expiry(key []byte) (uint64, bool) {
+	expiry, ok := e.expiry[string(key)]
+	return expiry, ok
+}
+
+// IterateExpired calls fn for each key that expires at or before the given
+// height, ordered by expiry height and then by key.
+func (e *ExpiryIndex) IterateExpired(height uint64, fn func(key []byte, expiry uint64)) {
+	for _, key := range e.expiredKeys(height) {
+		fn(key, e.expiry[string(key)])
+	}
+}
+
+// DeleteExpired removes all the keys that expire at or before the given height
+// from the trie and from the index, and returns the number of keys removed from
+// the trie. A key already deleted from the trie directly is only dropped from
+// the index, and is not counted.
+func (e *ExpiryIndex) DeleteExpired(height uint64) int {
+	removed := 0
+	for _, key := range e.expiredKeys(height) {
+		if e.Delete(key) {
+			removed++
+		}
+	}
+	return removed
+}
+
+func (e *ExpiryIndex) expiredKeys(height uint64) [][]byte {
+	keys := make([][]byte, 0)
+	for key, expiry := range e.expiry {
+		if expiry <= height {
+			keys = append(keys, []byte(key))
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		ei, ej := e.expiry[string(keys[i])], e.expiry[string(keys[j])]
+		if ei != ej {
+			return ei < ej
+		}
+		return bytes.Compare(keys[i], keys[j]) < 0
+	})
+	return keys
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpiryIndex(t *testing.T) {
+	tr := NewTrie()
+	index := NewExpiryIndex(tr)
+	index.Put([]byte{1}, []byte("a"), 10)
+	index.Put([]byte{2}, []byte("b"), 5)
+	index.Put([]byte{3}, []byte("c"), 20)
+	index.Put([]byte{4}, []byte("d"), 5)
+
+	t.Run("should iterate expired keys by expiry height", func(t *testing.T) {
+		keys := make([][]byte, 0)
+		index.IterateExpired(10, func(key []byte, expiry uint64) {
+			keys = append(keys, key)
+		})
+		require.Equal(t, [][]byte{{2}, {4}, {1}}, keys)
+	})
+
+	t.Run("should delete expired keys from the trie", func(t *testing.T) {
+		expected := NewTrie()
+		expected.Put([]byte{3}, []byte("c"))
+
+		require.Equal(t, 3, index.DeleteExpired(10))
+		require.Equal(t, expected.Hash(), tr.Hash())
+
+		_, ok := index.Expiry([]byte{1})
+		require.False(t, ok)
+		expiry, ok := index.Expiry([]byte{3})
+		require.True(t, ok)
+		require.Equal(t, uint64(20), expiry)
+
+		require.Equal(t, 0, index.DeleteExpired(10))
+	})
+
+	t.Run("should not count keys already deleted from the trie", func(t *testing.T) {
+		tr := NewTrie()
+		index := NewExpiryIndex(tr)
+		index.Put([]byte{1}, []byte("a"), 5)
+		index.Put([]byte{2}, []byte("b"), 5)
+		require.True(t, tr.Delete([]byte{1}))
+
+		require.Equal(t, 1, index.DeleteExpired(10))
+		_, ok := index.Expiry([]byte{1})
+		require.False(t, ok)
+	})
+}
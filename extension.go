@@ -23,11 +23,7 @@ func (e ExtensionNode) Hash() []byte {
 func (e ExtensionNode) Raw() []interface{} {
 	hashes := make([]interface{}, 2)
 	hashes[0] = ToBytes(ToPrefixed(e.Path, false))
-	if len(Serialize(e.Next)) >= 32 {
-		hashes[1] = e.Next.Hash()
-	} else {
-		hashes[1] = e.Next.Raw()
-	}
+	hashes[1] = RawRef(e.Next)
 	return hashes
 }
 
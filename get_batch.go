@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"sort"
+)
+
+// batchKey is a key of a GetBatch call, with the nibbles left to look up.
+type batchKey struct {
+	index   int
+	nibbles []Nibble
+}
+
+// GetBatch returns the values of the given keys, in the same order, with a nil
+// value for the keys that don't exist. The keys are sorted and looked up in a
+// single walk, so that the nodes shared by their paths are visited once.
+func (t *Trie) GetBatch(keys [][]byte) [][]byte {
+	sorted := make([]batchKey, 0, len(keys))
+	for i, key := range keys {
+		sorted = append(sorted, batchKey{index: i, nibbles: FromBytes(key)})
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return bytes.Compare(keys[sorted[i].index], keys[sorted[j].index]) < 0
+	})
+
+	values := make([][]byte, len(keys))
+	t.getBatch(t.root, sorted, values)
+	return values
+}
+
+// getBatch looks up the sorted keys under the given node, and sets the values
+// of the keys that are found.
+func (t *Trie) getBatch(node Node, keys []batchKey, values [][]byte) {
+	if IsEmptyNode(node) || len(keys) == 0 {
+		return
+	}
+
+	if leaf, ok := node.(*LeafNode); ok {
+		for _, key := range keys {
+			matched := PrefixMatchedLen(leaf.Path, key.nibbles)
+			if matched == len(leaf.Path) && matched == len(key.nibbles) {
+				values[key.index] = t.batchValue(leaf.Value)
+			}
+		}
+		return
+	}
+
+	if branch, ok := node.(*BranchNode); ok {
+		// the keys ending at the branch come first, followed by the keys of
+		// each child in nibble order
+		for len(keys) > 0 && len(keys[0].nibbles) == 0 {
+			if branch.HasValue() {
+				values[keys[0].index] = t.batchValue(branch.Value)
+			}
+			keys = keys[1:]
+		}
+
+		for len(keys) > 0 {
+			b := keys[0].nibbles[0]
+			end := 1
+			for end < len(keys) && keys[end].nibbles[0] == b {
+				end++
+			}
+
+			children := make([]batchKey, 0, end)
+			for _, key := range keys[:end] {
+				children = append(children, batchKey{index: key.index, nibbles: key.nibbles[1:]})
+			}
+			t.getBatch(branch.Branches[b], children, values)
+			keys = keys[end:]
+		}
+		return
+	}
+
+	if ext, ok := node.(*ExtensionNode); ok {
+		next := make([]batchKey, 0, len(keys))
+		for _, key := range keys {
+			if PrefixMatchedLen(ext.Path, key.nibbles) == len(ext.Path) {
+				next = append(next, batchKey{index: key.index, nibbles: key.nibbles[len(ext.Path):]})
+			}
+		}
+		t.getBatch(ext.Next, next, values)
+		return
+	}
+
+	// like Get, the keys under a node only known by its hash are not found
+	if _, ok := node.(HashNode); ok {
+		return
+	}
+
+	panic("unknown type")
+}
+
+func (t *Trie) batchValue(stored []byte) []byte {
+	if t.isTombstone(stored) {
+		return nil
+	}
+	return t.resolveValue(stored)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetBatch(t *testing.T) {
+	tr := NewTrie()
+	keys := make([][]byte, 0)
+	for i := 0; i < 100; i++ {
+		// keys of different lengths, so that some values are held by branch nodes
+		key := Keccak256([]byte{byte(i)})[:1+i%3]
+		keys = append(keys, key)
+		if i%4 != 0 {
+			tr.Put(key, []byte(fmt.Sprintf("value%v", i)))
+		}
+	}
+	keys = append(keys, []byte{}, keys[1])
+
+	t.Run("should return the same values as Get, in the order of the keys", func(t *testing.T) {
+		values := tr.GetBatch(keys)
+		require.Len(t, values, len(keys))
+		for i, key := range keys {
+			val, found := tr.Get(key)
+			if !found {
+				require.Nil(t, values[i], "%x", key)
+				continue
+			}
+			require.Equal(t, val, values[i], "%x", key)
+		}
+	})
+
+	t.Run("should return nil values on an empty trie", func(t *testing.T) {
+		require.Equal(t, [][]byte{nil, nil}, NewTrie().GetBatch(keys[:2]))
+	})
+}
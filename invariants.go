@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// CheckInvariants checks the structure of the trie, and returns an error
+// describing the first broken invariant:
+// - Nibbles are in the 0-15 range.
+// - An ExtensionNode has a non-empty path and points to a BranchNode.
+// - A BranchNode has at least two children, or a child and a value.
+// - A value is stored under a path of even length, since keys are bytes.
+// - A node small enough to be embedded in its parent decodes back to itself.
+func (t *Trie) CheckInvariants() error {
+	return checkNode(t.root, []Nibble{})
+}
+
+func checkNode(node Node, path []Nibble) error {
+	if IsEmptyNode(node) {
+		return nil
+	}
+
+	if _, ok := node.(HashNode); ok {
+		return nil
+	}
+
+	serialized := Serialize(node)
+	if len(serialized) < 32 {
+		decoded, err := DecodeNode(serialized)
+		if err != nil {
+			return fmt.Errorf("embedded node at path %x can't be decoded: %w", path, err)
+		}
+		if !bytes.Equal(serialized, Serialize(decoded)) {
+			return fmt.Errorf("embedded node at path %x does not decode to itself", path)
+		}
+	}
+
+	if leaf, ok := node.(*LeafNode); ok {
+		if err := checkNibbles(leaf.Path); err != nil {
+			return fmt.Errorf("leaf node at path %x: %w", path, err)
+		}
+		if (len(path)+len(leaf.Path))%2 != 0 {
+			return fmt.Errorf("leaf node at path %x has a key of odd length", path)
+		}
+		return nil
+	}
+
+	if branch, ok := node.(*BranchNode); ok {
+		children := 0
+		for i, child := range branch.Branches {
+			if IsEmptyNode(child) {
+				continue
+			}
+			children++
+			if err := checkNode(child, appendNibbles(path, Nibble(i))); err != nil {
+				return err
+			}
+		}
+
+		if branch.HasValue() && len(path)%2 != 0 {
+			return fmt.Errorf("branch node at path %x has a value for a key of odd length", path)
+		}
+
+		if children < 2 && !(children == 1 && branch.HasValue()) {
+			return fmt.Errorf("branch node at path %x should have been collapsed", path)
+		}
+		return nil
+	}
+
+	if ext, ok := node.(*ExtensionNode); ok {
+		if len(ext.Path) == 0 {
+			return fmt.Errorf("extension node at path %x has an empty path", path)
+		}
+		if err := checkNibbles(ext.Path); err != nil {
+			return fmt.Errorf("extension node at path %x: %w", path, err)
+		}
+
+		switch ext.Next.(type) {
+		case *BranchNode, HashNode:
+		default:
+			return fmt.Errorf("extension node at path %x does not point to a branch node", path)
+		}
+		return checkNode(ext.Next, appendNibbles(path, ext.Path...))
+	}
+
+	return fmt.Errorf("unknown node type %T at path %x", node, path)
+}
+
+func checkNibbles(nibbles []Nibble) error {
+	for _, n := range nibbles {
+		if !IsNibble(byte(n)) {
+			return fmt.Errorf("non-nibble in path: %v", n)
+		}
+	}
+	return nil
+}
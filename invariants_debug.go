@@ -0,0 +1,11 @@
+//go:build mptdebug
+
+package main
+
+// debugCheckInvariants panics if the trie breaks an invariant. It only runs
+// in builds with the mptdebug tag, such as `go test -tags mptdebug ./...`.
+func (t *Trie) debugCheckInvariants() {
+	if err := t.CheckInvariants(); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,7 @@
+//go:build !mptdebug
+
+package main
+
+// debugCheckInvariants does nothing, build with the mptdebug tag to check the
+// trie's invariants after every update.
+func (t *Trie) debugCheckInvariants() {}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckInvariants(t *testing.T) {
+	t.Run("should pass for tries built with Put and Delete", func(t *testing.T) {
+		require.NoError(t, NewTrie().CheckInvariants())
+		tr := newTestTrie(t, 100)
+		tr.Put([]byte("key"), []byte("value"))
+		require.NoError(t, tr.CheckInvariants())
+
+		for i := 0; i < 100; i += 2 {
+			tr.Delete(testKey(i))
+		}
+		require.NoError(t, tr.CheckInvariants())
+	})
+
+	t.Run("should fail for a branch node with a single child", func(t *testing.T) {
+		branch := NewBranchNode()
+		branch.SetBranch(1, NewLeafNodeFromNibbles([]Nibble{2}, []byte("hello")))
+		tr := &Trie{root: branch}
+		require.Error(t, tr.CheckInvariants())
+	})
+
+	t.Run("should fail for an extension node pointing to a leaf node", func(t *testing.T) {
+		leaf := NewLeafNodeFromNibbles([]Nibble{2}, []byte("hello"))
+		tr := &Trie{root: NewExtensionNode([]Nibble{1}, leaf)}
+		require.Error(t, tr.CheckInvariants())
+	})
+
+	t.Run("should fail for a key of odd length", func(t *testing.T) {
+		tr := &Trie{root: NewLeafNodeFromNibbles([]Nibble{1, 2, 3}, []byte("hello"))}
+		require.Error(t, tr.CheckInvariants())
+	})
+}
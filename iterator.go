@@ -0,0 +1,207 @@
+package main
+
+// Iterator walks the key value pairs of a trie in lexicographic key order.
+// The trie must not be updated while iterating.
+//
+//	it := trie.Iterator()
+//	for it.Next() {
+//		fmt.Printf("%x: %x\n", it.Key(), it.Value())
+//	}
+//	if it.Err() != nil { ... }
+type Iterator struct {
+	trie  *Trie
+	stack []iteratorItem
+	key   []byte
+	value []byte
+	err   error
+
+	// raw is true to return the values as stored in the leaves, tombstones
+	// included, instead of the values that Get would return
+	raw bool
+}
+
+// iteratorItem is a node to visit, and the path from the root to it.
+type iteratorItem struct {
+	node Node
+	path []Nibble
+}
+
+// Iterator returns an iterator positioned before the first key of the trie.
+func (t *Trie) Iterator() *Iterator {
+	it := &Iterator{trie: t}
+	if !IsEmptyNode(t.root) {
+		it.stack = append(it.stack, iteratorItem{node: t.root, path: []Nibble{}})
+	}
+	return it
+}
+
+// Seek moves the iterator before the first key that is greater than or equal
+// to the given key, so that the following call to Next moves to that key.
+func (it *Iterator) Seek(start []byte) {
+	it.stack = it.stack[:0]
+	it.key, it.value, it.err = nil, nil, nil
+	it.seek(it.trie.root, []Nibble{}, FromBytes(start))
+}
+
+// seek pushes the nodes under the given node that contain keys greater than or
+// equal to the path followed by the target nibbles, the smallest last.
+func (it *Iterator) seek(node Node, path []Nibble, target []Nibble) {
+	if IsEmptyNode(node) {
+		return
+	}
+
+	if leaf, ok := node.(*LeafNode); ok {
+		if compareNibbles(leaf.Path, target) >= 0 {
+			it.stack = append(it.stack, iteratorItem{node: node, path: path})
+		}
+		return
+	}
+
+	if branch, ok := node.(*BranchNode); ok {
+		if len(target) == 0 {
+			it.stack = append(it.stack, iteratorItem{node: node, path: path})
+			return
+		}
+
+		// the children after the target nibble only have greater keys, and the
+		// value of the branch has a smaller key
+		for i := 15; i > int(target[0]); i-- {
+			if !IsEmptyNode(branch.Branches[i]) {
+				it.stack = append(it.stack, iteratorItem{
+					node: branch.Branches[i],
+					path: appendNibbles(path, Nibble(i)),
+				})
+			}
+		}
+		it.seek(branch.Branches[target[0]], appendNibbles(path, target[0]), target[1:])
+		return
+	}
+
+	if ext, ok := node.(*ExtensionNode); ok {
+		n := len(ext.Path)
+		if len(target) < n {
+			n = len(target)
+		}
+
+		compared := compareNibbles(ext.Path[:n], target[:n])
+		if compared < 0 {
+			return
+		}
+
+		// if the target is a prefix of the extension path, all the keys under
+		// it are greater than the target too
+		if compared > 0 || len(target) <= len(ext.Path) {
+			it.stack = append(it.stack, iteratorItem{node: node, path: path})
+			return
+		}
+
+		it.seek(ext.Next, appendNibbles(path, ext.Path...), target[len(ext.Path):])
+		return
+	}
+
+	if _, ok := node.(HashNode); ok {
+		// the node can't be looked into, Next will fail with ErrNotCovered
+		it.stack = append(it.stack, iteratorItem{node: node, path: path})
+		return
+	}
+
+	panic("unknown type")
+}
+
+// compareNibbles compares two nibble slices in lexicographic order, a slice
+// being smaller than the slices it is a prefix of.
+func compareNibbles(a []Nibble, b []Nibble) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	if len(a) < len(b) {
+		return -1
+	}
+	if len(a) > len(b) {
+		return 1
+	}
+	return 0
+}
+
+// Next moves the iterator to the next key, and returns false when there
+// are no more keys, or when the iteration failed, see Err.
+func (it *Iterator) Next() bool {
+	for len(it.stack) > 0 && it.err == nil {
+		item := it.stack[len(it.stack)-1]
+		it.stack = it.stack[:len(it.stack)-1]
+
+		if leaf, ok := item.node.(*LeafNode); ok {
+			if it.raw {
+				it.key = ToBytes(appendNibbles(item.path, leaf.Path...))
+				it.value = leaf.Value
+				return true
+			}
+			if it.trie.isTombstone(leaf.Value) {
+				continue
+			}
+			it.key = ToBytes(appendNibbles(item.path, leaf.Path...))
+			it.value = it.trie.resolveValue(leaf.Value)
+			return true
+		}
+
+		if branch, ok := item.node.(*BranchNode); ok {
+			// push in reverse order, so that the smallest nibble is visited first
+			for i := 15; i >= 0; i-- {
+				if !IsEmptyNode(branch.Branches[i]) {
+					it.stack = append(it.stack, iteratorItem{
+						node: branch.Branches[i],
+						path: appendNibbles(item.path, Nibble(i)),
+					})
+				}
+			}
+			// the branch value's key is a prefix of its children's keys, so it comes first
+			if branch.HasValue() {
+				it.stack = append(it.stack, iteratorItem{
+					node: NewLeafNodeFromNibbles([]Nibble{}, branch.Value),
+					path: item.path,
+				})
+			}
+			continue
+		}
+
+		if ext, ok := item.node.(*ExtensionNode); ok {
+			it.stack = append(it.stack, iteratorItem{
+				node: ext.Next,
+				path: appendNibbles(item.path, ext.Path...),
+			})
+			continue
+		}
+
+		if _, ok := item.node.(HashNode); ok {
+			it.err = ErrNotCovered
+			break
+		}
+
+		panic("unknown type")
+	}
+
+	it.key, it.value = nil, nil
+	return false
+}
+
+// Key returns the key at the current position.
+func (it *Iterator) Key() []byte {
+	return it.key
+}
+
+// Value returns the value at the current position.
+func (it *Iterator) Value() []byte {
+	return it.value
+}
+
+// Err returns the error that stopped the iteration, which is ErrNotCovered if
+// a node only known by its hash was reached.
+func (it *Iterator) Err() error {
+	return it.err
+}
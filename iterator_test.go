@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIterator(t *testing.T) {
+	t.Run("should not iterate an empty trie", func(t *testing.T) {
+		it := NewTrie().Iterator()
+		require.False(t, it.Next())
+		require.NoError(t, it.Err())
+	})
+
+	t.Run("should iterate all pairs in key order", func(t *testing.T) {
+		tr := newTestTrie(t, 100)
+		keys := testKeys(100)
+		// keys that are prefixes of other keys are stored in branch nodes
+		for _, key := range [][]byte{{}, []byte("key"), []byte("k")} {
+			keys = append(keys, key)
+			tr.Put(key, append([]byte("value"), key...))
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			return bytes.Compare(keys[i], keys[j]) < 0
+		})
+
+		it := tr.Iterator()
+		iterated := make([][]byte, 0)
+		for it.Next() {
+			iterated = append(iterated, it.Key())
+			value, found := tr.Get(it.Key())
+			require.True(t, found)
+			require.Equal(t, value, it.Value())
+		}
+		require.NoError(t, it.Err())
+		require.Equal(t, keys, iterated)
+	})
+
+	t.Run("should stop with ErrNotCovered in a trie built from a proof", func(t *testing.T) {
+		partial := newPartialTestTrie(t)
+
+		it := partial.Iterator()
+		for it.Next() {
+		}
+		require.True(t, errors.Is(it.Err(), ErrNotCovered))
+	})
+}
+
+func TestIteratorSeek(t *testing.T) {
+	tr := NewTrie()
+	keys := make([][]byte, 0)
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 300; i++ {
+		key := make([]byte, r.Intn(4))
+		r.Read(key)
+		if _, found := tr.Get(key); !found {
+			keys = append(keys, key)
+		}
+		tr.Put(key, []byte(fmt.Sprintf("value%v", i)))
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return bytes.Compare(keys[i], keys[j]) < 0
+	})
+
+	starts := [][]byte{{}, {0}, {0xff, 0xff, 0xff, 0xff}}
+	for i := 0; i < 100; i++ {
+		start := make([]byte, r.Intn(4))
+		r.Read(start)
+		starts = append(starts, start)
+	}
+	// existing keys
+	starts = append(starts, keys[0], keys[len(keys)/2], keys[len(keys)-1])
+
+	for _, start := range starts {
+		expected := make([][]byte, 0)
+		for _, key := range keys {
+			if bytes.Compare(key, start) >= 0 {
+				expected = append(expected, key)
+			}
+		}
+
+		it := tr.Iterator()
+		it.Seek(start)
+		iterated := make([][]byte, 0)
+		for it.Next() {
+			iterated = append(iterated, it.Key())
+		}
+		require.NoError(t, it.Err())
+		require.Equal(t, expected, iterated, "start: %x", start)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import "github.com/ethereum/go-ethereum/rlp"
+
+// LocateNode returns the path from the root to the node with the given hash.
+// Identical subtrees have the same hash, so a hash can match nodes at several
+// paths, in which case the smallest path is returned, see LocateNodes.
+func (t *Trie) LocateNode(hash []byte) ([]Nibble, bool) {
+	paths := t.LocateNodes(hash)
+	if len(paths) == 0 {
+		return nil, false
+	}
+	return paths[0], true
+}
+
+// LocateNodes returns the paths from the root to all the nodes with the given
+// hash, in increasing order.
+// Nodes only known by their hash, such as the ones missing from the proof of
+// a trie created by NewTrieFromProof, can be located too.
+// The index of node hashes is built on the first call, and rebuilt after the
+// trie is updated. It is built under a lock, so LocateNodes can run concurrently
+// with other reads.
+func (t *Trie) LocateNodes(hash []byte) [][]Nibble {
+	t.nodeIndexMu.Lock()
+	defer t.nodeIndexMu.Unlock()
+
+	if t.nodeIndex == nil {
+		t.nodeIndex = make(map[string][][]Nibble)
+		indexNodes(t.root, []Nibble{}, t.nodeIndex)
+	}
+
+	// the index is kept, the caller gets copies of its paths
+	paths := make([][]Nibble, 0, len(t.nodeIndex[string(hash)]))
+	for _, path := range t.nodeIndex[string(hash)] {
+		paths = append(paths, appendNibbles(path))
+	}
+	return paths
+}
+
+// indexNodes adds the paths of the node and of its descendants to the index,
+// and returns the raw reference to the node from its parent, see RawRef.
+// The node is serialized from the references returned for its children, so
+// that each node is hashed once, rather than once per ancestor.
+func indexNodes(node Node, path []Nibble, index map[string][][]Nibble) interface{} {
+	if IsEmptyNode(node) {
+		return EmptyNodeRaw
+	}
+
+	if hash, ok := node.(HashNode); ok {
+		index[string(hash)] = append(index[string(hash)], path)
+		return []byte(hash)
+	}
+
+	var raw []interface{}
+	if leaf, ok := node.(*LeafNode); ok {
+		raw = leaf.Raw()
+	}
+
+	if branch, ok := node.(*BranchNode); ok {
+		raw = make([]interface{}, 17)
+		for i, child := range branch.Branches {
+			raw[i] = indexNodes(child, appendNibbles(path, Nibble(i)), index)
+		}
+		raw[16] = branch.Value
+	}
+
+	if ext, ok := node.(*ExtensionNode); ok {
+		raw = []interface{}{
+			ToBytes(ToPrefixed(ext.Path, false)),
+			indexNodes(ext.Next, appendNibbles(path, ext.Path...), index),
+		}
+	}
+
+	serialized, err := rlp.EncodeToBytes(raw)
+	if err != nil {
+		panic(err)
+	}
+	hash := Keccak256(serialized)
+
+	// a node and its descendants can't have the same hash, and the children
+	// are visited in order, so the paths of a hash are sorted
+	index[string(hash)] = append(index[string(hash)], path)
+
+	if len(serialized) >= 32 {
+		return hash
+	}
+	return raw
+}
+
+// appendNibbles returns a new slice with the nibbles appended to the path,
+// leaving the path untouched.
+func appendNibbles(path []Nibble, nibbles ...Nibble) []Nibble {
+	appended := make([]Nibble, 0, len(path)+len(nibbles))
+	appended = append(appended, path...)
+	return append(appended, nibbles...)
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocateNode(t *testing.T) {
+	tr := NewTrie()
+	tr.Put([]byte{1, 2, 3, 4}, []byte("hello1"))
+	tr.Put([]byte{1, 2, 3, 5}, []byte("hello2"))
+	tr.Put([]byte{1, 2, 5}, []byte("world"))
+
+	t.Run("should locate the root and internal nodes", func(t *testing.T) {
+		path, ok := tr.LocateNode(tr.Hash())
+		require.True(t, ok)
+		require.Equal(t, []Nibble{}, path)
+
+		branch := tr.root.(*ExtensionNode).Next.(*BranchNode)
+		path, ok = tr.LocateNode(branch.Hash())
+		require.True(t, ok)
+		require.Equal(t, []Nibble{0, 1, 0, 2, 0}, path)
+
+		path, ok = tr.LocateNode(branch.Branches[5].Hash())
+		require.True(t, ok)
+		require.Equal(t, []Nibble{0, 1, 0, 2, 0, 5}, path)
+	})
+
+	t.Run("should not locate unknown nodes", func(t *testing.T) {
+		_, ok := tr.LocateNode(EmptyNodeHash)
+		require.False(t, ok)
+		require.Empty(t, tr.LocateNodes(EmptyNodeHash))
+	})
+
+	t.Run("should locate all the identical subtrees", func(t *testing.T) {
+		identical := NewTrie()
+		identical.Put([]byte{0x10, 0x01}, []byte("same"))
+		identical.Put([]byte{0x20, 0x01}, []byte("same"))
+		identical.Put([]byte{0x30, 0x01}, []byte("other"))
+
+		root := identical.root.(*BranchNode)
+		require.Equal(t, root.Branches[1].Hash(), root.Branches[2].Hash())
+
+		paths := identical.LocateNodes(root.Branches[1].Hash())
+		require.Equal(t, [][]Nibble{{1}, {2}}, paths)
+		path, ok := identical.LocateNode(root.Branches[2].Hash())
+		require.True(t, ok)
+		require.Equal(t, []Nibble{1}, path)
+
+		require.Equal(t, [][]Nibble{{3}}, identical.LocateNodes(root.Branches[3].Hash()))
+	})
+
+	t.Run("should not let callers modify the located paths", func(t *testing.T) {
+		branch := tr.root.(*ExtensionNode).Next.(*BranchNode)
+		paths := tr.LocateNodes(branch.Hash())
+		paths[0][0] = 0xf
+		paths[0] = nil
+
+		path, ok := tr.LocateNode(branch.Hash())
+		require.True(t, ok)
+		require.Equal(t, []Nibble{0, 1, 0, 2, 0}, path)
+	})
+
+	t.Run("should index every node by its hash", func(t *testing.T) {
+		big := newTestTrie(t, 100)
+		it := big.NodeIterator()
+		for it.Next() {
+			path, ok := big.LocateNode(it.Node().Hash())
+			require.True(t, ok)
+			require.Equal(t, it.Path(), path)
+		}
+	})
+
+	t.Run("should locate nodes concurrently", func(t *testing.T) {
+		big := newTestTrie(t, 100)
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				path, ok := big.LocateNode(big.Hash())
+				require.True(t, ok)
+				require.Equal(t, []Nibble{}, path)
+				big.Get(testKey(7))
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("should locate nodes added after an update", func(t *testing.T) {
+		tr.Put([]byte{1, 2, 6}, []byte("trie"))
+		path, ok := tr.LocateNode(tr.Hash())
+		require.True(t, ok)
+		require.Equal(t, []Nibble{}, path)
+	})
+
+	t.Run("should locate nodes missing from a proof", func(t *testing.T) {
+		big := newTestTrie(t, 50)
+		partial := newPartialTestTrie(t)
+
+		// the first node on the path to key30 that is not on the path to key7
+		// is only known by its hash
+		covered, _ := big.pathNodes(testKey(7))
+		missing, _ := big.pathNodes(testKey(30))
+		var first Node
+		for i, node := range missing {
+			if i >= len(covered) || covered[i] != node {
+				first = node
+				break
+			}
+		}
+		require.NotNil(t, first)
+
+		expected, ok := big.LocateNode(first.Hash())
+		require.True(t, ok)
+		path, ok := partial.LocateNode(first.Hash())
+		require.True(t, ok)
+		require.Equal(t, expected, path)
+	})
+}
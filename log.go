@@ -0,0 +1,62 @@
+package main
+
+import "github.com/ethereum/go-ethereum/rlp"
+
+// Log is an authenticated append-only list backed by a trie. The item at index
+// i is stored under the key rlp(i), the same way a block stores transactions
+// in its transactions trie, so a Log of transactions has the transactionsRoot
+// as its Root.
+type Log struct {
+	trie   *Trie
+	length int
+}
+
+func NewLog() *Log {
+	return &Log{
+		trie: NewTrie(),
+	}
+}
+
+// LogKey returns the key the item at the given index is stored under.
+func LogKey(index int) []byte {
+	key, err := rlp.EncodeToBytes(uint(index))
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+// Append adds an item at the end of the log, and returns its index.
+func (l *Log) Append(item []byte) int {
+	index := l.length
+	l.trie.Put(LogKey(index), item)
+	l.length++
+	return index
+}
+
+// Len returns the number of items in the log.
+func (l *Log) Len() int {
+	return l.length
+}
+
+// Root returns the root hash of the trie holding the items.
+func (l *Log) Root() []byte {
+	return l.trie.Hash()
+}
+
+// Get returns the item at the given index.
+func (l *Log) Get(index int) ([]byte, bool) {
+	if index < 0 || index >= l.length {
+		return nil, false
+	}
+	return l.trie.Get(LogKey(index))
+}
+
+// ProveItem returns the merkle proof for the item at the given index, which
+// can be verified with VerifyProof(root, LogKey(index), proof).
+func (l *Log) ProveItem(index int) (Proof, bool) {
+	if index < 0 || index >= l.length {
+		return nil, false
+	}
+	return l.trie.Prove(LogKey(index))
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLog(t *testing.T) {
+	txs := TransactionsJSON(t)
+
+	log := NewLog()
+	require.Equal(t, 0, log.Len())
+	require.Equal(t, EmptyNodeHash, log.Root())
+
+	for i, tx := range txs {
+		rlp, err := FromEthTransaction(tx).GetRLP()
+		require.NoError(t, err)
+		require.Equal(t, i, log.Append(rlp))
+	}
+
+	t.Run("root should match the transactions root", func(t *testing.T) {
+		require.Equal(t, len(txs), log.Len())
+		require.Equal(t, types.DeriveSha(types.Transactions(txs)).Bytes(), log.Root())
+	})
+
+	t.Run("should get items by index", func(t *testing.T) {
+		item, found := log.Get(30)
+		require.True(t, found)
+		rlp, err := FromEthTransaction(txs[30]).GetRLP()
+		require.NoError(t, err)
+		require.Equal(t, rlp, item)
+
+		_, found = log.Get(len(txs))
+		require.False(t, found)
+		_, found = log.Get(-1)
+		require.False(t, found)
+	})
+
+	t.Run("should prove items by index", func(t *testing.T) {
+		proof, found := log.ProveItem(30)
+		require.True(t, found)
+		item, err := VerifyProof(log.Root(), LogKey(30), proof)
+		require.NoError(t, err)
+		rlp, err := FromEthTransaction(txs[30]).GetRLP()
+		require.NoError(t, err)
+		require.Equal(t, rlp, item)
+
+		_, found = log.ProveItem(len(txs))
+		require.False(t, found)
+	})
+}
@@ -77,6 +77,31 @@ func ToPrefixed(ns []Nibble, isLeafNode bool) []Nibble {
 	return prefixed
 }
 
+// FromPrefixed removes the nibble prefix added by ToPrefixed, and returns
+// the original nibbles and whether the prefix indicts a leaf node.
+func FromPrefixed(prefixed []Nibble) ([]Nibble, bool, error) {
+	if len(prefixed) == 0 {
+		return nil, false, fmt.Errorf("missing prefix")
+	}
+
+	prefix := prefixed[0]
+	if prefix > 3 {
+		return nil, false, fmt.Errorf("invalid prefix: %v", prefix)
+	}
+
+	isLeafNode := prefix >= 2
+	// odd number of nibbles
+	if prefix%2 == 1 {
+		return prefixed[1:], isLeafNode, nil
+	}
+
+	// even number of nibbles, the prefix was padded with a 0 nibble
+	if len(prefixed) < 2 || prefixed[1] != 0 {
+		return nil, false, fmt.Errorf("invalid prefix padding")
+	}
+	return prefixed[2:], isLeafNode, nil
+}
+
 // ToBytes converts a slice of nibbles to a byte slice
 // assuming the nibble slice has even number of nibbles.
 func ToBytes(ns []Nibble) []byte {
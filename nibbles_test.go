@@ -88,3 +88,19 @@ func TestPrefixMatchedLen(t *testing.T) {
 	require.Equal(t, 4, PrefixMatchedLen([]Nibble{0, 1, 2, 3}, []Nibble{0, 1, 2, 3}))
 	require.Equal(t, 4, PrefixMatchedLen([]Nibble{0, 1, 2, 3}, []Nibble{0, 1, 2, 3, 4}))
 }
+
+func TestFromPrefixed(t *testing.T) {
+	for _, ns := range [][]Nibble{{}, {1}, {1, 2}, {5, 0, 6}, {14, 3}} {
+		for _, isLeafNode := range []bool{true, false} {
+			decoded, isLeaf, err := FromPrefixed(ToPrefixed(ns, isLeafNode))
+			require.NoError(t, err)
+			require.Equal(t, ns, decoded)
+			require.Equal(t, isLeafNode, isLeaf)
+		}
+	}
+
+	_, _, err := FromPrefixed([]Nibble{4, 1})
+	require.Error(t, err)
+	_, _, err = FromPrefixed([]Nibble{0, 1})
+	require.Error(t, err)
+}
@@ -0,0 +1,127 @@
+package main
+
+// NodeType is the type of a node, as reported by NodeIterator.
+type NodeType int
+
+const (
+	LeafNodeType NodeType = iota
+	ExtensionNodeType
+	BranchNodeType
+	// HashNodeType is a node only known by its hash, see HashNode.
+	HashNodeType
+)
+
+func (t NodeType) String() string {
+	switch t {
+	case LeafNodeType:
+		return "leaf"
+	case ExtensionNodeType:
+		return "extension"
+	case BranchNodeType:
+		return "branch"
+	case HashNodeType:
+		return "hash"
+	}
+	return "unknown"
+}
+
+// NodeIterator walks all the nodes of a trie, each node before its children,
+// and the children of a branch node in nibble order.
+// The trie must not be updated while iterating.
+type NodeIterator struct {
+	stack []iteratorItem
+	item  iteratorItem
+}
+
+// NodeIterator returns an iterator positioned before the root node.
+func (t *Trie) NodeIterator() *NodeIterator {
+	it := &NodeIterator{}
+	if !IsEmptyNode(t.root) {
+		it.stack = append(it.stack, iteratorItem{node: t.root, path: []Nibble{}})
+	}
+	return it
+}
+
+// Next moves the iterator to the next node, and returns false when there are
+// no more nodes.
+func (it *NodeIterator) Next() bool {
+	if len(it.stack) == 0 {
+		it.item = iteratorItem{}
+		return false
+	}
+
+	it.item = it.stack[len(it.stack)-1]
+	it.stack = it.stack[:len(it.stack)-1]
+
+	if branch, ok := it.item.node.(*BranchNode); ok {
+		// push in reverse order, so that the smallest nibble is visited first
+		for i := 15; i >= 0; i-- {
+			if !IsEmptyNode(branch.Branches[i]) {
+				it.stack = append(it.stack, iteratorItem{
+					node: branch.Branches[i],
+					path: appendNibbles(it.item.path, Nibble(i)),
+				})
+			}
+		}
+	}
+
+	if ext, ok := it.item.node.(*ExtensionNode); ok {
+		it.stack = append(it.stack, iteratorItem{
+			node: ext.Next,
+			path: appendNibbles(it.item.path, ext.Path...),
+		})
+	}
+
+	return true
+}
+
+// Node returns the node at the current position.
+func (it *NodeIterator) Node() Node {
+	return it.item.node
+}
+
+// Path returns the path from the root to the node at the current position.
+func (it *NodeIterator) Path() []Nibble {
+	return it.item.path
+}
+
+// Hash returns the hash of the node at the current position.
+func (it *NodeIterator) Hash() []byte {
+	return it.item.node.Hash()
+}
+
+// Type returns the type of the node at the current position.
+func (it *NodeIterator) Type() NodeType {
+	switch it.item.node.(type) {
+	case *LeafNode:
+		return LeafNodeType
+	case *ExtensionNode:
+		return ExtensionNodeType
+	case *BranchNode:
+		return BranchNodeType
+	case HashNode:
+		return HashNodeType
+	}
+	panic("unknown type")
+}
+
+// Blob returns the serialized node at the current position, or nil for a node
+// only known by its hash.
+func (it *NodeIterator) Blob() []byte {
+	if _, ok := it.item.node.(HashNode); ok {
+		return nil
+	}
+	return Serialize(it.item.node)
+}
+
+// Embedded returns whether the node at the current position is small enough
+// to be embedded in its parent rather than referenced by its hash.
+func (it *NodeIterator) Embedded() bool {
+	if len(it.item.path) == 0 {
+		return false
+	}
+	if _, ok := it.item.node.(HashNode); ok {
+		return false
+	}
+	return len(Serialize(it.item.node)) < 32
+}
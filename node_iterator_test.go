@@ -0,0 +1,78 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodeIterator(t *testing.T) {
+	t.Run("should not iterate an empty trie", func(t *testing.T) {
+		require.False(t, NewTrie().NodeIterator().Next())
+	})
+
+	t.Run("should iterate the nodes before their children", func(t *testing.T) {
+		tr := NewTrie()
+		tr.Put([]byte{1, 2, 3, 4}, []byte("hello1"))
+		tr.Put([]byte{1, 2, 3, 5}, []byte("hello2"))
+		tr.Put([]byte{1, 2, 5}, []byte("world"))
+
+		types := make([]string, 0)
+		paths := make([][]Nibble, 0)
+		it := tr.NodeIterator()
+		for it.Next() {
+			types = append(types, it.Type().String())
+			paths = append(paths, it.Path())
+		}
+
+		require.Equal(t, []string{"extension", "branch", "extension", "branch", "leaf", "leaf", "leaf"}, types)
+		require.Equal(t, [][]Nibble{
+			{},
+			{0, 1, 0, 2, 0},
+			{0, 1, 0, 2, 0, 3},
+			{0, 1, 0, 2, 0, 3, 0},
+			{0, 1, 0, 2, 0, 3, 0, 4},
+			{0, 1, 0, 2, 0, 3, 0, 5},
+			{0, 1, 0, 2, 0, 5},
+		}, paths)
+	})
+
+	t.Run("should report the hash and serialized form of each node", func(t *testing.T) {
+		tr := newTestTrie(t, 50)
+
+		it := tr.NodeIterator()
+		require.True(t, it.Next())
+		require.Equal(t, tr.Hash(), it.Hash())
+
+		// every value is either in a leaf node or in a branch node
+		values := 0
+		for it.Next() {
+			require.Equal(t, Keccak256(it.Blob()), it.Hash())
+			located, ok := tr.LocateNode(it.Hash())
+			require.True(t, ok)
+			require.Equal(t, located, it.Path())
+
+			if it.Type() == LeafNodeType {
+				values++
+			}
+			if branch, ok := it.Node().(*BranchNode); ok && branch.HasValue() {
+				values++
+			}
+		}
+		require.Equal(t, 50, values)
+	})
+
+	t.Run("should report nodes only known by their hash", func(t *testing.T) {
+		partial := newPartialTestTrie(t)
+
+		hashNodes := 0
+		it := partial.NodeIterator()
+		for it.Next() {
+			if it.Type() == HashNodeType {
+				hashNodes++
+				require.Nil(t, it.Blob())
+			}
+		}
+		require.Greater(t, hashNodes, 0)
+	})
+}
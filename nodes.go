@@ -30,3 +30,21 @@ func Serialize(node Node) []byte {
 
 	return rlp
 }
+
+// RawRef returns the raw form of the reference to a node from its parent node.
+func RawRef(node Node) interface{} {
+	if hash, ok := node.(HashNode); ok {
+		return []byte(hash)
+	}
+
+	if len(Serialize(node)) >= 32 {
+		return node.Hash()
+	}
+
+	// if node can be serialized to less than 32 bits, then
+	// use Serialized directly.
+	// it has to be ">=", rather than ">",
+	// so that when deserialized, the content can be distinguished
+	// by length
+	return node.Raw()
+}
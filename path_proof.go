@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// ProvePath returns the proof for the node at the given path, which can be any
+// node of the trie, not only a leaf. The path has to end at the node, it can't
+// end in the middle of an extension node or a leaf node's path.
+// It also returns the hash of the node, which is the root hash for an empty path.
+// The proof contains the nodes from the root down to the parent of the node.
+// It returns false if the path contains a value that is not a nibble.
+func (t *Trie) ProvePath(path []Nibble) (Proof, []byte, bool) {
+	if err := checkNibbles(path); err != nil {
+		return nil, nil, false
+	}
+
+	proof := NewProofDB()
+	node := t.root
+
+	for {
+		if IsEmptyNode(node) {
+			return nil, nil, false
+		}
+
+		if len(path) == 0 {
+			return proof, node.Hash(), true
+		}
+
+		// the content of a hash node is unknown, it can't be part of a proof
+		if _, ok := node.(HashNode); ok {
+			return nil, nil, false
+		}
+
+		proof.Put(Hash(node), Serialize(node))
+
+		if _, ok := node.(*LeafNode); ok {
+			return nil, nil, false
+		}
+
+		if branch, ok := node.(*BranchNode); ok {
+			b, remaining := path[0], path[1:]
+			path = remaining
+			node = branch.Branches[b]
+			continue
+		}
+
+		if ext, ok := node.(*ExtensionNode); ok {
+			matched := PrefixMatchedLen(ext.Path, path)
+			if matched < len(ext.Path) {
+				return nil, nil, false
+			}
+
+			path = path[matched:]
+			node = ext.Next
+			continue
+		}
+
+		panic("not found")
+	}
+}
+
+// VerifyPathProof verifies the proof returned by ProvePath for the node at the
+// given path under the given root hash.
+// It returns the hash of the node if the proof is valid, otherwise error will be returned.
+func VerifyPathProof(rootHash []byte, path []Nibble, proof Proof) ([]byte, error) {
+	if err := checkNibbles(path); err != nil {
+		return nil, err
+	}
+
+	var node Node = HashNode(rootHash)
+	walked := 0
+
+	for {
+		if len(path) == 0 {
+			return node.Hash(), nil
+		}
+
+		if hash, ok := node.(HashNode); ok {
+			data, err := proof.Get(hash)
+			if err != nil {
+				return nil, fmt.Errorf("missing proof node %x at path %x: %w", []byte(hash), path, err)
+			}
+			if !bytes.Equal(Keccak256(data), hash) {
+				return nil, fmt.Errorf("proof node at path %x does not match hash %x", path, []byte(hash))
+			}
+			node, err = DecodeNode(data)
+			if err != nil {
+				return nil, fmt.Errorf("invalid proof node %x: %w", []byte(hash), err)
+			}
+		}
+
+		if _, ok := node.(*LeafNode); ok {
+			return nil, fmt.Errorf("path ends at a leaf node after %v nibbles", walked)
+		}
+
+		if branch, ok := node.(*BranchNode); ok {
+			b, remaining := path[0], path[1:]
+			if IsEmptyNode(branch.Branches[b]) {
+				return nil, fmt.Errorf("no node at path after %v nibbles", walked)
+			}
+			path = remaining
+			node = branch.Branches[b]
+			walked++
+			continue
+		}
+
+		if ext, ok := node.(*ExtensionNode); ok {
+			matched := PrefixMatchedLen(ext.Path, path)
+			if matched < len(ext.Path) {
+				return nil, fmt.Errorf("path diverges from extension node after %v nibbles", walked+matched)
+			}
+
+			path = path[matched:]
+			node = ext.Next
+			walked += matched
+			continue
+		}
+
+		panic("unknown type")
+	}
+}
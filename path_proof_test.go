@@ -0,0 +1,84 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProveAndVerifyPath(t *testing.T) {
+	tr := NewTrie()
+	tr.Put([]byte{1, 2, 3, 4}, []byte("hello1"))
+	tr.Put([]byte{1, 2, 3, 5}, []byte("hello2"))
+	tr.Put([]byte{1, 2, 5}, []byte("a value long enough to not be embedded in the parent node"))
+	rootHash := tr.Hash()
+
+	// E 01020
+	// B [3]: E 0 -> B [4]: L hello1, [5]: L hello2
+	//   [5]: L
+	ext := tr.root.(*ExtensionNode)
+	branch := ext.Next.(*BranchNode)
+
+	t.Run("should prove the root", func(t *testing.T) {
+		proof, hash, ok := tr.ProvePath([]Nibble{})
+		require.True(t, ok)
+		require.Equal(t, rootHash, hash)
+
+		verified, err := VerifyPathProof(rootHash, []Nibble{}, proof)
+		require.NoError(t, err)
+		require.Equal(t, rootHash, verified)
+	})
+
+	t.Run("should prove an internal node", func(t *testing.T) {
+		path := []Nibble{0, 1, 0, 2, 0}
+		proof, hash, ok := tr.ProvePath(path)
+		require.True(t, ok)
+		require.Equal(t, branch.Hash(), hash)
+
+		verified, err := VerifyPathProof(rootHash, path, proof)
+		require.NoError(t, err)
+		require.Equal(t, branch.Hash(), verified)
+	})
+
+	t.Run("should prove a node embedded in its parent", func(t *testing.T) {
+		path := []Nibble{0, 1, 0, 2, 0, 3}
+		proof, hash, ok := tr.ProvePath(path)
+		require.True(t, ok)
+		require.Equal(t, branch.Branches[3].Hash(), hash)
+
+		verified, err := VerifyPathProof(rootHash, path, proof)
+		require.NoError(t, err)
+		require.Equal(t, hash, verified)
+	})
+
+	t.Run("should not prove a path ending inside an extension node", func(t *testing.T) {
+		_, _, ok := tr.ProvePath([]Nibble{0, 1})
+		require.False(t, ok)
+
+		proof, _, ok := tr.ProvePath([]Nibble{0, 1, 0, 2, 0})
+		require.True(t, ok)
+		_, err := VerifyPathProof(rootHash, []Nibble{0, 1}, proof)
+		require.Error(t, err)
+	})
+
+	t.Run("should fail the verification under a different root", func(t *testing.T) {
+		path := []Nibble{0, 1, 0, 2, 0}
+		proof, _, ok := tr.ProvePath(path)
+		require.True(t, ok)
+		_, err := VerifyPathProof(EmptyNodeHash, path, proof)
+		require.Error(t, err)
+	})
+
+	t.Run("should reject a path with a value that is not a nibble", func(t *testing.T) {
+		path := []Nibble{0, 1, 0, 2, 0, 16}
+		proof, hash, ok := tr.ProvePath(path)
+		require.False(t, ok)
+		require.Nil(t, proof)
+		require.Nil(t, hash)
+
+		proof, _, ok = tr.ProvePath([]Nibble{0, 1, 0, 2, 0})
+		require.True(t, ok)
+		_, err := VerifyPathProof(rootHash, path, proof)
+		require.Error(t, err)
+	})
+}
@@ -1,10 +1,8 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
-
-	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/trie"
 )
 
 type Proof interface {
@@ -24,8 +22,12 @@ type Proof interface {
 	Serialize() [][]byte
 }
 
+// ProofDB is a Proof kept in memory. Its nodes are serialized in the order they
+// were first put, which for the proofs returned by Prove is the order of the
+// proof path, from the root down.
 type ProofDB struct {
-	kv map[string][]byte
+	kv   map[string][]byte
+	keys []string
 }
 
 func NewProofDB() *ProofDB {
@@ -36,14 +38,25 @@ func NewProofDB() *ProofDB {
 
 func (w *ProofDB) Put(key []byte, value []byte) error {
 	keyS := fmt.Sprintf("%x", key)
+	if _, ok := w.kv[keyS]; !ok {
+		w.keys = append(w.keys, keyS)
+	}
 	w.kv[keyS] = value
-	fmt.Printf("put key: %x, value: %x\n", key, value)
 	return nil
 }
 
 func (w *ProofDB) Delete(key []byte) error {
 	keyS := fmt.Sprintf("%x", key)
+	if _, ok := w.kv[keyS]; !ok {
+		return nil
+	}
 	delete(w.kv, keyS)
+	for i, k := range w.keys {
+		if k == keyS {
+			w.keys = append(w.keys[:i], w.keys[i+1:]...)
+			break
+		}
+	}
 	return nil
 }
 func (w *ProofDB) Has(key []byte) (bool, error) {
@@ -62,38 +75,63 @@ func (w *ProofDB) Get(key []byte) ([]byte, error) {
 }
 
 func (w *ProofDB) Serialize() [][]byte {
-	nodes := make([][]byte, 0, len(w.kv))
-	for _, value := range w.kv {
-		nodes = append(nodes, value)
+	nodes := make([][]byte, 0, len(w.keys))
+	for _, key := range w.keys {
+		nodes = append(nodes, w.kv[key])
 	}
 	return nodes
 }
 
-// Prove returns the merkle proof for the given key, which is
+// Prove returns the merkle proof for the given key, which is the nodes from the
+// root down to the node holding the value, keyed by their hash.
+// It returns false if the key does not exist. It also returns false if the key
+// can't be looked up in a trie created by NewTrieFromProof, use TryProve to
+// tell the two apart.
 func (t *Trie) Prove(key []byte) (Proof, bool) {
+	proof, found, _ := t.TryProve(key)
+	return proof, found
+}
+
+// TryProve is like Prove, but returns ErrNotCovered if the key can't be looked
+// up because its path reaches a node only known by its hash, which happens in a
+// trie created by NewTrieFromProof.
+func (t *Trie) TryProve(key []byte) (Proof, bool, error) {
+	if t.panicHook != nil {
+		defer t.recoverPanic(t.recordOperation("prove", key))
+	}
+
 	proof := NewProofDB()
 	node := t.root
 	nibbles := FromBytes(key)
 
 	for {
-		proof.Put(Hash(node), Serialize(node))
-
 		if IsEmptyNode(node) {
-			return nil, false
+			return nil, false, nil
 		}
 
+		// the content of a hash node is unknown, it can't be part of a proof
+		if _, ok := node.(HashNode); ok {
+			return nil, false, ErrNotCovered
+		}
+
+		proof.Put(Hash(node), Serialize(node))
+
 		if leaf, ok := node.(*LeafNode); ok {
 			matched := PrefixMatchedLen(leaf.Path, nibbles)
 			if matched != len(leaf.Path) || matched != len(nibbles) {
-				return nil, false
+				return nil, false, nil
 			}
 
-			return proof, true
+			return proof, true, nil
 		}
 
 		if branch, ok := node.(*BranchNode); ok {
+			// the key is a prefix of other keys, its value is held by the branch
 			if len(nibbles) == 0 {
-				return proof, branch.HasValue()
+				if !branch.HasValue() {
+					return nil, false, nil
+				}
+				return proof, true, nil
 			}
 
 			b, remaining := nibbles[0], nibbles[1:]
@@ -107,7 +145,7 @@ func (t *Trie) Prove(key []byte) (Proof, bool) {
 			// E 01020304
 			//   010203
 			if matched < len(ext.Path) {
-				return nil, false
+				return nil, false, nil
 			}
 
 			nibbles = nibbles[matched:]
@@ -119,8 +157,95 @@ func (t *Trie) Prove(key []byte) (Proof, bool) {
 	}
 }
 
-// VerifyProof verify the proof for the given key under the given root hash using go-ethereum's VerifyProof implementation.
-// It returns the value for the key if the proof is valid, otherwise error will be returned
+// ProveHashes returns the hashes of the nodes on the path to the given key, from
+// the root down to the node holding the value, without the nodes themselves.
+// Two parties holding the same data can compare them to check their paths are
+// consistent, for a fraction of the size of a proof.
+func (t *Trie) ProveHashes(key []byte) ([][]byte, bool) {
+	nodes, found := t.pathNodes(key)
+	if !found {
+		return nil, false
+	}
+
+	hashes := make([][]byte, 0, len(nodes))
+	for _, node := range nodes {
+		hashes = append(hashes, node.Hash())
+	}
+	return hashes, true
+}
+
+// VerifyProof verify the proof for the given key under the given root hash,
+// by walking down the proof nodes from the root and checking each node matches
+// the hash its parent references it by.
+// It returns the value for the key if the proof is valid, otherwise error will be returned.
+// Like go-ethereum's VerifyProof, it returns a nil value without error if the
+// proof is valid and shows that the key is not in the trie.
 func VerifyProof(rootHash []byte, key []byte, proof Proof) (value []byte, err error) {
-	return trie.VerifyProof(common.BytesToHash(rootHash), key, proof)
+	_, value, err = walkProof(rootHash, key, proof)
+	return value, err
+}
+
+// walkProof walks down the proof nodes along the path of the key, and returns
+// the serialized nodes it went through, not including the nodes embedded in
+// their parent, and the value of the key, which is nil if it doesn't exist.
+func walkProof(rootHash []byte, key []byte, proof Proof) ([][]byte, []byte, error) {
+	var node Node = HashNode(rootHash)
+	nibbles := FromBytes(key)
+	nodes := make([][]byte, 0)
+
+	for {
+		if hash, ok := node.(HashNode); ok {
+			data, err := proof.Get(hash)
+			if err != nil {
+				return nil, nil, fmt.Errorf("missing proof node %x: %w", []byte(hash), err)
+			}
+			if !bytes.Equal(Keccak256(data), hash) {
+				return nil, nil, fmt.Errorf("proof node does not match hash %x", []byte(hash))
+			}
+			node, err = DecodeNode(data)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid proof node %x: %w", []byte(hash), err)
+			}
+			nodes = append(nodes, data)
+		}
+
+		if IsEmptyNode(node) {
+			return nodes, nil, nil
+		}
+
+		if leaf, ok := node.(*LeafNode); ok {
+			matched := PrefixMatchedLen(leaf.Path, nibbles)
+			if matched != len(leaf.Path) || matched != len(nibbles) {
+				return nodes, nil, nil
+			}
+			return nodes, leaf.Value, nil
+		}
+
+		if branch, ok := node.(*BranchNode); ok {
+			if len(nibbles) == 0 {
+				if !branch.HasValue() {
+					return nodes, nil, nil
+				}
+				return nodes, branch.Value, nil
+			}
+
+			b, remaining := nibbles[0], nibbles[1:]
+			nibbles = remaining
+			node = branch.Branches[b]
+			continue
+		}
+
+		if ext, ok := node.(*ExtensionNode); ok {
+			matched := PrefixMatchedLen(ext.Path, nibbles)
+			if matched < len(ext.Path) {
+				return nodes, nil, nil
+			}
+
+			nibbles = nibbles[matched:]
+			node = ext.Next
+			continue
+		}
+
+		panic("unknown type")
+	}
 }
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// ProofDB is a key-value store of go-ethereum too, so it can be passed to
+// go-ethereum's trie.Prove to collect a proof, and to trie.VerifyProof to
+// verify one.
+var (
+	_ ethdb.KeyValueReader = (*ProofDB)(nil)
+	_ ethdb.KeyValueWriter = (*ProofDB)(nil)
+)
+
+// NewProofDBFromNodes creates a ProofDB from a list of serialized nodes, such as
+// the one returned by Proof.Serialize or go-ethereum's StateDB.GetProof,
+// storing each node under its hash.
+func NewProofDBFromNodes(nodes [][]byte) *ProofDB {
+	proof := NewProofDB()
+	for _, node := range nodes {
+		proof.Put(Keccak256(node), node)
+	}
+	return proof
+}
+
+// NewProofDBFromHex creates a ProofDB from the nodes of a proof returned by
+// eth_getProof, such as the accountProof or the proof of a storage slot.
+func NewProofDBFromHex(nodes []hexutil.Bytes) *ProofDB {
+	raw := make([][]byte, 0, len(nodes))
+	for _, node := range nodes {
+		raw = append(raw, node)
+	}
+	return NewProofDBFromNodes(raw)
+}
+
+// NewProofDBFromHexStrings creates a ProofDB from a list of 0x prefixed hex
+// encoded nodes, the format go-ethereum uses to serve proofs over RPC.
+func NewProofDBFromHexStrings(nodes []string) (*ProofDB, error) {
+	raw := make([][]byte, 0, len(nodes))
+	for i, node := range nodes {
+		bytes, err := hexutil.Decode(node)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode proof node %v: %w", i, err)
+		}
+		raw = append(raw, bytes)
+	}
+	return NewProofDBFromNodes(raw), nil
+}
+
+// ProofToHex converts a proof to the format of the proofs returned by eth_getProof.
+func ProofToHex(proof Proof) []hexutil.Bytes {
+	nodes := proof.Serialize()
+	hex := make([]hexutil.Bytes, 0, len(nodes))
+	for _, node := range nodes {
+		hex = append(hex, node)
+	}
+	return hex
+}
+
+// ProofToHexStrings converts a proof to a list of 0x prefixed hex encoded nodes.
+func ProofToHexStrings(proof Proof) []string {
+	nodes := proof.Serialize()
+	hex := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		hex = append(hex, hexutil.Encode(node))
+	}
+	return hex
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	ethtrie "github.com/ethereum/go-ethereum/trie"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProofConversion(t *testing.T) {
+	tr := NewTrie()
+	tr.Put([]byte{1, 2, 3}, []byte("hello"))
+	tr.Put([]byte{1, 2, 3, 4, 5}, []byte("world"))
+	tr.Put([]byte{5, 6, 7}, []byte("trie"))
+	key := []byte{1, 2, 3}
+	proof, ok := tr.Prove(key)
+	require.True(t, ok)
+
+	t.Run("should verify a proof rebuilt from serialized nodes", func(t *testing.T) {
+		val, err := VerifyProof(tr.Hash(), key, NewProofDBFromNodes(proof.Serialize()))
+		require.NoError(t, err)
+		require.Equal(t, []byte("hello"), val)
+	})
+
+	t.Run("should verify a proof rebuilt from hex", func(t *testing.T) {
+		val, err := VerifyProof(tr.Hash(), key, NewProofDBFromHex(ProofToHex(proof)))
+		require.NoError(t, err)
+		require.Equal(t, []byte("hello"), val)
+	})
+
+	t.Run("should verify a proof rebuilt from hex strings", func(t *testing.T) {
+		fromHex, err := NewProofDBFromHexStrings(ProofToHexStrings(proof))
+		require.NoError(t, err)
+		val, err := VerifyProof(tr.Hash(), key, fromHex)
+		require.NoError(t, err)
+		require.Equal(t, []byte("hello"), val)
+	})
+
+	t.Run("should be verified by go-ethereum from its proof list", func(t *testing.T) {
+		val, err := ethtrie.VerifyProof(common.BytesToHash(tr.Hash()), key, NewProofDBFromNodes(proof.Serialize()))
+		require.NoError(t, err)
+		require.Equal(t, []byte("hello"), val)
+
+		val, err = ethtrie.VerifyProof(common.BytesToHash(tr.Hash()), key, proof.(*ProofDB))
+		require.NoError(t, err)
+		require.Equal(t, []byte("hello"), val)
+	})
+
+	t.Run("should verify a proof collected by go-ethereum", func(t *testing.T) {
+		ethTrie := new(ethtrie.Trie)
+		ethTrie.Update([]byte{1, 2, 3}, []byte("hello"))
+		ethTrie.Update([]byte{1, 2, 3, 4, 5}, []byte("world"))
+		ethTrie.Update([]byte{5, 6, 7}, []byte("trie"))
+
+		ethProof := NewProofDB()
+		require.NoError(t, ethTrie.Prove(key, 0, ethProof))
+		val, err := VerifyProof(tr.Hash(), key, ethProof)
+		require.NoError(t, err)
+		require.Equal(t, []byte("hello"), val)
+		require.Equal(t, proof.Serialize(), ethProof.Serialize())
+	})
+
+	t.Run("should fail to decode invalid hex strings", func(t *testing.T) {
+		_, err := NewProofDBFromHexStrings([]string{"0xzz"})
+		require.Error(t, err)
+	})
+
+	t.Run("should verify the accountProof from eth_getProof", func(t *testing.T) {
+		byteValue, err := ioutil.ReadFile("eip1186_proof.json")
+		require.NoError(t, err)
+		var response EthRPCGetProofResponse
+		require.NoError(t, json.Unmarshal(byteValue, &response))
+
+		// https://etherscan.io/block/14900001
+		stateRootHash := common.HexToHash("0x024c056bc5db60d71c7908c5fad6050646bd70fd772ff222702d577e2af2e56b")
+		account := common.HexToAddress("0xB856af30B938B6f52e5BfF365675F358CD52F91B")
+
+		_, err = VerifyProof(stateRootHash.Bytes(), crypto.Keccak256(account.Bytes()),
+			NewProofDBFromHex(response.Result.AccountProof))
+		require.NoError(t, err)
+	})
+}
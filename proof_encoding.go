@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/golang/snappy"
+)
+
+// MaxProofSize and MaxProofNodeSize are the largest decoded proof, and the
+// largest node in it, that ReadProof and DecodeProof accept. Decompressed
+// data counts towards MaxProofSize, so that a small compressed input can't
+// expand without bounds.
+var (
+	MaxProofSize     = 64 << 20
+	MaxProofNodeSize = 1 << 20
+)
+
+// Compression is the compression of an encoded proof, see EncodeProof.
+type Compression byte
+
+const (
+	NoCompression Compression = iota
+	GzipCompression
+	SnappyCompression
+)
+
+func (c Compression) String() string {
+	switch c {
+	case NoCompression:
+		return "none"
+	case GzipCompression:
+		return "gzip"
+	case SnappyCompression:
+		return "snappy"
+	}
+	return fmt.Sprintf("unknown(%v)", byte(c))
+}
+
+// EncodeProof encodes the nodes of the proof into a single byte slice, in the
+// order of Serialize, compressed with the given compression. The first byte
+// tells the compression, so that DecodeProof decompresses it transparently.
+// Proof nodes are mostly RLP structure and hashes shared between nodes, large
+// proofs get much smaller when compressed.
+func EncodeProof(proof Proof, compression Compression) ([]byte, error) {
+	var buf bytes.Buffer
+	_, err := WriteProof(&buf, proof, compression)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DecodeProof decodes a proof encoded by EncodeProof, whatever its compression.
+func DecodeProof(data []byte) (*ProofDB, error) {
+	return ReadProof(bytes.NewReader(data))
+}
+
+// WriteProof writes the proof to w in the format of EncodeProof, one node at a
+// time, and returns the number of bytes written.
+func WriteProof(w io.Writer, proof Proof, compression Compression) (int64, error) {
+	counter := &countingWriter{w: w}
+	_, err := counter.Write([]byte{byte(compression)})
+	if err != nil {
+		return counter.n, fmt.Errorf("could not write proof: %w", err)
+	}
+
+	var cw io.WriteCloser
+	switch compression {
+	case NoCompression:
+		cw = nopCloser{counter}
+	case GzipCompression:
+		cw = gzip.NewWriter(counter)
+	case SnappyCompression:
+		cw = snappy.NewBufferedWriter(counter)
+	default:
+		return counter.n, fmt.Errorf("unknown compression: %v", compression)
+	}
+
+	// the nodes are RLP lists, which can be read back one by one without
+	// a separator
+	for _, node := range proof.Serialize() {
+		_, err := cw.Write(node)
+		if err != nil {
+			return counter.n, fmt.Errorf("could not write proof with %v compression: %w", compression, err)
+		}
+	}
+	err = cw.Close()
+	if err != nil {
+		return counter.n, fmt.Errorf("could not write proof with %v compression: %w", compression, err)
+	}
+	return counter.n, nil
+}
+
+// ReadProof reads a proof written by WriteProof or EncodeProof, whatever its
+// compression.
+func ReadProof(r io.Reader) (*ProofDB, error) {
+	proof := NewProofDB()
+	_, err := proof.ReadFrom(r)
+	if err != nil {
+		return nil, err
+	}
+	return proof, nil
+}
+
+// WriteTo writes the proof to w without compression, see WriteProof.
+func (w *ProofDB) WriteTo(out io.Writer) (int64, error) {
+	return WriteProof(out, w, NoCompression)
+}
+
+// ReadFrom reads the nodes of a proof written by WriteProof, whatever its
+// compression, and adds them to the proof. The nodes are decoded one at a time
+// as they are read.
+func (w *ProofDB) ReadFrom(r io.Reader) (int64, error) {
+	counter := &countingReader{r: r}
+	var header [1]byte
+	_, err := io.ReadFull(counter, header[:])
+	if err == io.EOF {
+		return counter.n, fmt.Errorf("empty encoded proof")
+	}
+	if err != nil {
+		return counter.n, fmt.Errorf("could not read proof: %w", err)
+	}
+
+	compression := Compression(header[0])
+	var cr io.Reader = counter
+	switch compression {
+	case NoCompression:
+	case GzipCompression:
+		gr, err := gzip.NewReader(counter)
+		if err != nil {
+			return counter.n, fmt.Errorf("could not read proof with %v compression: %w", compression, err)
+		}
+		cr = gr
+	case SnappyCompression:
+		cr = snappy.NewReader(counter)
+	default:
+		return counter.n, fmt.Errorf("unknown compression: %v", compression)
+	}
+
+	// the input is untrusted: the stream limit makes the RLP decoder reject
+	// sizes larger than what is left of MaxProofSize, before allocating them
+	br := bufio.NewReader(cr)
+	stream := rlp.NewStream(br, uint64(MaxProofSize))
+	for i := 0; ; i++ {
+		kind, size, err := stream.Kind()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return counter.n, fmt.Errorf("could not decode proof node %v: %w", i, err)
+		}
+		if kind != rlp.List {
+			return counter.n, fmt.Errorf("proof node %v is not a list", i)
+		}
+		if size > uint64(MaxProofNodeSize) {
+			return counter.n, fmt.Errorf("proof node %v is larger than %v bytes", i, MaxProofNodeSize)
+		}
+
+		node, err := stream.Raw()
+		if err != nil {
+			return counter.n, fmt.Errorf("could not decode proof node %v: %w", i, err)
+		}
+		w.Put(Keccak256(node), node)
+	}
+
+	// the stream also ends when the limit is reached
+	_, err = br.ReadByte()
+	if err == nil {
+		return counter.n, fmt.Errorf("proof is larger than %v bytes", MaxProofSize)
+	}
+	if err != io.EOF {
+		return counter.n, fmt.Errorf("could not read proof with %v compression: %w", compression, err)
+	}
+	return counter.n, nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// nopCloser is a io.WriteCloser doing nothing on Close.
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeProof(t *testing.T) {
+	tr := newTestTrie(t, 500)
+	proof, _ := tr.ProveBatchShared(testKeys(100))
+	uncompressed, err := EncodeProof(proof, NoCompression)
+	require.NoError(t, err)
+
+	for _, compression := range []Compression{NoCompression, GzipCompression, SnappyCompression} {
+		t.Run(fmt.Sprintf("should decode the proof compressed with %v", compression), func(t *testing.T) {
+			data, err := EncodeProof(proof, compression)
+			require.NoError(t, err)
+			if compression != NoCompression {
+				require.Less(t, len(data), len(uncompressed))
+			}
+
+			decoded, err := DecodeProof(data)
+			require.NoError(t, err)
+			require.Equal(t, proof.Serialize(), decoded.Serialize())
+
+			val, err := VerifyProof(tr.Hash(), testKey(42), decoded)
+			require.NoError(t, err)
+			require.Equal(t, testValue(42), val)
+		})
+	}
+
+	t.Run("should fail to decode invalid data", func(t *testing.T) {
+		_, err := DecodeProof([]byte{})
+		require.Error(t, err)
+
+		_, err = DecodeProof([]byte{9, 1, 2})
+		require.Error(t, err)
+
+		data, err := EncodeProof(proof, GzipCompression)
+		require.NoError(t, err)
+		_, err = DecodeProof(data[:len(data)/2])
+		require.Error(t, err)
+	})
+}
+
+func TestStreamProof(t *testing.T) {
+	tr := newTestTrie(t, 100)
+	keys := testKeys(100)
+	shared, _ := tr.ProveBatchShared(keys)
+	proof := shared.(*ProofDB)
+
+	t.Run("should write and read back the proof", func(t *testing.T) {
+		var buf bytes.Buffer
+		written, err := proof.WriteTo(&buf)
+		require.NoError(t, err)
+		require.Equal(t, int64(buf.Len()), written)
+
+		encoded, err := EncodeProof(proof, NoCompression)
+		require.NoError(t, err)
+		require.Equal(t, encoded, buf.Bytes())
+
+		read := NewProofDB()
+		n, err := read.ReadFrom(&buf)
+		require.NoError(t, err)
+		require.Equal(t, written, n)
+		require.Equal(t, proof.Serialize(), read.Serialize())
+	})
+
+	t.Run("should stream a compressed proof through a pipe", func(t *testing.T) {
+		r, w := io.Pipe()
+		go func() {
+			_, err := WriteProof(w, proof, SnappyCompression)
+			w.CloseWithError(err)
+		}()
+
+		read, err := ReadProof(r)
+		require.NoError(t, err)
+		val, err := VerifyProof(tr.Hash(), keys[42], read)
+		require.NoError(t, err)
+		require.Equal(t, testValue(42), val)
+	})
+
+	t.Run("should stream a proof to a file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "proof")
+		f, err := os.Create(path)
+		require.NoError(t, err)
+		_, err = WriteProof(f, proof, GzipCompression)
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+
+		f, err = os.Open(path)
+		require.NoError(t, err)
+		defer f.Close()
+		read, err := ReadProof(f)
+		require.NoError(t, err)
+		require.Equal(t, proof.Serialize(), read.Serialize())
+	})
+}
+
+func TestReadProofLimits(t *testing.T) {
+	t.Run("should reject list headers larger than the input limit", func(t *testing.T) {
+		for _, data := range [][]byte{
+			{0x00, 0xfc, 0x7f, 0xff, 0xff, 0xff, 0xc0},
+			{0x00, 0xff, 0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xc0},
+		} {
+			_, err := DecodeProof(data)
+			require.Error(t, err, "%x", data)
+		}
+	})
+
+	t.Run("should reject malformed headers", func(t *testing.T) {
+		for _, data := range [][]byte{
+			{0x00, 0xf8},             // truncated length
+			{0x00, 0xf8, 0x01, 0xc0}, // non canonical size
+			{0x00, 0x81, 0x80},       // not a list
+		} {
+			_, err := DecodeProof(data)
+			require.Error(t, err, "%x", data)
+		}
+	})
+
+	t.Run("should reject a node larger than MaxProofNodeSize", func(t *testing.T) {
+		node, err := rlp.EncodeToBytes([][]byte{make([]byte, MaxProofNodeSize)})
+		require.NoError(t, err)
+		_, err = DecodeProof(append([]byte{byte(NoCompression)}, node...))
+		require.Error(t, err)
+	})
+
+	t.Run("should reject a proof larger than MaxProofSize", func(t *testing.T) {
+		defer func(size int) { MaxProofSize = size }(MaxProofSize)
+		MaxProofSize = 1000
+
+		node, err := rlp.EncodeToBytes([][]byte{make([]byte, 100)})
+		require.NoError(t, err)
+		data := []byte{byte(NoCompression)}
+		for i := 0; i < 20; i++ {
+			data = append(data, node...)
+		}
+		_, err = DecodeProof(data)
+		require.Error(t, err)
+
+		_, err = DecodeProof(data[:1+5*len(node)])
+		require.NoError(t, err)
+	})
+}
+
+func TestDecodeProofDecompressionBomb(t *testing.T) {
+	defer func(size int) { MaxProofSize = size }(MaxProofSize)
+	MaxProofSize = 1 << 20
+
+	// a valid node repeated many times compresses to a tiny fraction of the
+	// decompressed size
+	node, err := rlp.EncodeToBytes([][]byte{make([]byte, 1000)})
+	require.NoError(t, err)
+	nodes := make([][]byte, 0)
+	for i := 0; i < 2*MaxProofSize/len(node); i++ {
+		nodes = append(nodes, node)
+	}
+	proof := &repeatedProof{nodes: nodes}
+
+	for _, compression := range []Compression{GzipCompression, SnappyCompression} {
+		t.Run(fmt.Sprintf("should stop decompressing %v past MaxProofSize", compression), func(t *testing.T) {
+			data, err := EncodeProof(proof, compression)
+			require.NoError(t, err)
+			require.Less(t, len(data), MaxProofSize/10)
+
+			_, err = DecodeProof(data)
+			require.Error(t, err)
+		})
+	}
+}
+
+// repeatedProof serializes to the same node many times, which ProofDB can't
+// since it keys nodes by hash.
+type repeatedProof struct {
+	ProofDB
+	nodes [][]byte
+}
+
+func (p *repeatedProof) Serialize() [][]byte {
+	return p.nodes
+}
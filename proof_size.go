@@ -0,0 +1,158 @@
+package main
+
+// EstimateProofSize returns the number of bytes of the proof that Prove would
+// generate for the given key, computed from the shape of the trie without
+// serializing the proof nodes.
+// It returns false if the key does not exist, in which case Prove would not
+// return a proof either.
+func (t *Trie) EstimateProofSize(key []byte) (int, bool) {
+	nodes, found := t.pathNodes(key)
+	if !found {
+		return 0, false
+	}
+
+	size := 0
+	for _, node := range nodes {
+		size += encodedSize(node)
+	}
+	return size, true
+}
+
+// EstimateWitnessSize returns the number of bytes needed to witness the reads
+// and writes of the given keys, that is the total size of the distinct nodes
+// visited while looking up each key. Keys that don't exist are included too,
+// since the nodes up to where their path diverges are needed to show that.
+func (t *Trie) EstimateWitnessSize(readKeys [][]byte, writeKeys [][]byte) int {
+	visited := make(map[Node]struct{})
+	size := 0
+	keys := append(append([][]byte{}, readKeys...), writeKeys...)
+	for _, key := range keys {
+		nodes, _ := t.pathNodes(key)
+		for _, node := range nodes {
+			if _, ok := visited[node]; ok {
+				continue
+			}
+			visited[node] = struct{}{}
+			size += encodedSize(node)
+		}
+	}
+	return size
+}
+
+// pathNodes returns the nodes visited from the root while looking up the key,
+// and whether the key was found.
+func (t *Trie) pathNodes(key []byte) ([]Node, bool) {
+	nodes := make([]Node, 0)
+	node := t.root
+	nibbles := FromBytes(key)
+
+	for {
+		if IsEmptyNode(node) {
+			return nodes, false
+		}
+
+		// the content of a hash node is unknown, it can't be part of a proof
+		if _, ok := node.(HashNode); ok {
+			return nodes, false
+		}
+
+		nodes = append(nodes, node)
+
+		if leaf, ok := node.(*LeafNode); ok {
+			matched := PrefixMatchedLen(leaf.Path, nibbles)
+			return nodes, matched == len(leaf.Path) && matched == len(nibbles)
+		}
+
+		if branch, ok := node.(*BranchNode); ok {
+			if len(nibbles) == 0 {
+				return nodes, branch.HasValue()
+			}
+
+			b, remaining := nibbles[0], nibbles[1:]
+			nibbles = remaining
+			node = branch.Branches[b]
+			continue
+		}
+
+		if ext, ok := node.(*ExtensionNode); ok {
+			matched := PrefixMatchedLen(ext.Path, nibbles)
+			if matched < len(ext.Path) {
+				return nodes, false
+			}
+
+			nibbles = nibbles[matched:]
+			node = ext.Next
+			continue
+		}
+
+		panic("unknown type")
+	}
+}
+
+// encodedSize returns the length of Serialize(node) by adding up the RLP
+// header and payload sizes of the node's fields.
+func encodedSize(node Node) int {
+	if IsEmptyNode(node) {
+		return rlpStringSize(EmptyNodeRaw)
+	}
+
+	switch n := node.(type) {
+	case *LeafNode:
+		path := ToBytes(ToPrefixed(n.Path, true))
+		return rlpListSize(rlpStringSize(path) + rlpStringSize(n.Value))
+	case *ExtensionNode:
+		path := ToBytes(ToPrefixed(n.Path, false))
+		return rlpListSize(rlpStringSize(path) + childRefSize(n.Next))
+	case *BranchNode:
+		payload := 0
+		for i := 0; i < 16; i++ {
+			if n.Branches[i] == nil {
+				payload += rlpStringSize(EmptyNodeRaw)
+			} else {
+				payload += childRefSize(n.Branches[i])
+			}
+		}
+		payload += rlpStringSize(n.Value)
+		return rlpListSize(payload)
+	}
+
+	panic("unknown type")
+}
+
+// childRefSize returns the size of the reference to a child node inside its
+// parent: either the child's hash or, for small nodes, the child itself.
+func childRefSize(node Node) int {
+	if _, ok := node.(HashNode); ok {
+		return 33
+	}
+
+	size := encodedSize(node)
+	if size >= 32 {
+		// a 32 bytes hash, encoded as a string
+		return 33
+	}
+	return size
+}
+
+func rlpStringSize(b []byte) int {
+	if len(b) == 1 && b[0] < 0x80 {
+		return 1
+	}
+	return rlpHeaderSize(len(b)) + len(b)
+}
+
+func rlpListSize(payload int) int {
+	return rlpHeaderSize(payload) + payload
+}
+
+func rlpHeaderSize(length int) int {
+	if length < 56 {
+		return 1
+	}
+	size := 1
+	for length > 0 {
+		size++
+		length >>= 8
+	}
+	return size
+}
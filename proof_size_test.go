@@ -0,0 +1,52 @@
+package main
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimateProofSize(t *testing.T) {
+	tr := NewTrie()
+	keys := make([][]byte, 0)
+	for i := 0; i < 100; i++ {
+		key := sha256.Sum256([]byte{byte(i)})
+		keys = append(keys, key[:4])
+		tr.Put(key[:4], key[:])
+	}
+	// short keys and values, so that some nodes are embedded in their parents
+	tr.Put([]byte{1, 2, 3}, []byte("hello"))
+	tr.Put([]byte{1, 2, 3, 4, 5}, []byte("world"))
+	keys = append(keys, []byte{1, 2, 3}, []byte{1, 2, 3, 4, 5})
+
+	t.Run("should match the size of the generated proof", func(t *testing.T) {
+		for _, key := range keys {
+			proof, ok := tr.Prove(key)
+			require.True(t, ok)
+
+			expected := 0
+			for _, node := range proof.Serialize() {
+				expected += len(node)
+			}
+
+			size, ok := tr.EstimateProofSize(key)
+			require.True(t, ok)
+			require.Equal(t, expected, size)
+		}
+	})
+
+	t.Run("should not estimate proof for non-exist key", func(t *testing.T) {
+		_, ok := tr.EstimateProofSize([]byte{1, 2, 3, 4})
+		require.False(t, ok)
+	})
+
+	t.Run("should count shared nodes once in the witness", func(t *testing.T) {
+		size1, _ := tr.EstimateProofSize(keys[0])
+		size2, _ := tr.EstimateProofSize(keys[1])
+		witness := tr.EstimateWitnessSize([][]byte{keys[0]}, [][]byte{keys[1]})
+		require.Equal(t, len(Serialize(tr.root)), encodedSize(tr.root))
+		require.Less(t, witness, size1+size2)
+		require.Equal(t, size1, tr.EstimateWitnessSize([][]byte{keys[0]}, [][]byte{keys[0]}))
+	})
+}
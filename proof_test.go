@@ -1,9 +1,11 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
 	"testing"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/trie"
 	"github.com/stretchr/testify/require"
 )
@@ -82,3 +84,156 @@ func TestProveAndVerifyProof(t *testing.T) {
 		require.Error(t, err)
 	})
 }
+
+func TestProveBranchValue(t *testing.T) {
+	// each key is a prefix of the next ones, so that all the values but the
+	// last one are held by branch nodes
+	keys := [][]byte{
+		{},
+		{1},
+		{1, 2},
+		{1, 2, 3},
+		{1, 2, 3, 4},
+		{1, 2, 4},
+		{1, 3},
+	}
+
+	for _, valueSize := range []int{1, 40} {
+		tr := NewTrie()
+		for i, key := range keys {
+			tr.Put(key, bytes.Repeat([]byte{byte(i + 1)}, valueSize))
+		}
+
+		t.Run(fmt.Sprintf("should prove keys with values of %v bytes", valueSize), func(t *testing.T) {
+			for i, key := range keys {
+				proof, ok := tr.Prove(key)
+				require.True(t, ok, "%x", key)
+
+				val, err := trie.VerifyProof(common.BytesToHash(tr.Hash()), key, proof)
+				require.NoError(t, err, "%x", key)
+				require.Equal(t, bytes.Repeat([]byte{byte(i + 1)}, valueSize), val)
+			}
+		})
+
+		t.Run(fmt.Sprintf("should not prove a branch without value, values of %v bytes", valueSize), func(t *testing.T) {
+			tr := NewTrie()
+			tr.Put([]byte{1, 0x20}, bytes.Repeat([]byte{1}, valueSize))
+			tr.Put([]byte{1, 0x30}, bytes.Repeat([]byte{2}, valueSize))
+			proof, ok := tr.Prove([]byte{1})
+			require.False(t, ok)
+			require.Nil(t, proof)
+		})
+	}
+}
+
+func TestVerifyProofMatchesEth(t *testing.T) {
+	mpt := new(trie.Trie)
+	keys := make([][]byte, 0)
+	for i := 0; i < 200; i++ {
+		// keys of different lengths, so that some values are held by branch nodes
+		key := Keccak256([]byte{byte(i)})[:1+i%4]
+		keys = append(keys, key)
+		if i%2 == 0 {
+			mpt.Update(key, []byte(fmt.Sprintf("value%v", i)))
+		}
+	}
+	rootHash := mpt.Hash()
+
+	t.Run("should return the same value as go-ethereum's VerifyProof", func(t *testing.T) {
+		for _, key := range keys {
+			proof := NewProofDB()
+			require.NoError(t, mpt.Prove(key, 0, proof))
+
+			expected, err := trie.VerifyProof(rootHash, key, proof)
+			require.NoError(t, err)
+			val, err := VerifyProof(rootHash.Bytes(), key, proof)
+			require.NoError(t, err)
+			require.Equal(t, expected, val)
+		}
+	})
+
+	t.Run("should fail if a proof node is missing or doesn't match its hash", func(t *testing.T) {
+		proof := NewProofDB()
+		require.NoError(t, mpt.Prove(keys[0], 0, proof))
+		_, err := VerifyProof(Keccak256([]byte("other root")), keys[0], proof)
+		require.Error(t, err)
+
+		tampered := NewProofDB()
+		for _, node := range proof.Serialize() {
+			tampered.Put(Keccak256(node), node)
+		}
+		root, err := tampered.Get(rootHash.Bytes())
+		require.NoError(t, err)
+		tampered.Put(rootHash.Bytes(), append([]byte{}, root[:len(root)-1]...))
+		_, err = VerifyProof(rootHash.Bytes(), keys[0], tampered)
+		require.Error(t, err)
+	})
+}
+
+func TestProofSerializeOrder(t *testing.T) {
+	tr := newTestTrie(t, 100)
+	key := testKey(42)
+
+	t.Run("should serialize the nodes in proof path order", func(t *testing.T) {
+		proof, ok := tr.Prove(key)
+		require.True(t, ok)
+		hashes, ok := tr.ProveHashes(key)
+		require.True(t, ok)
+
+		nodes := proof.Serialize()
+		require.Len(t, nodes, len(hashes))
+		for i, node := range nodes {
+			require.Equal(t, hashes[i], Keccak256(node))
+		}
+	})
+
+	t.Run("should serialize the same proof byte for byte", func(t *testing.T) {
+		proof1, _ := tr.Prove(key)
+		proof2, _ := tr.Prove(key)
+		require.Equal(t, proof1.Serialize(), proof2.Serialize())
+		require.Equal(t, proof1.Serialize(), NewProofDBFromNodes(proof1.Serialize()).Serialize())
+	})
+
+	t.Run("should keep the order after deleting a node", func(t *testing.T) {
+		proof := NewProofDB()
+		for _, node := range [][]byte{{1}, {2}, {3}} {
+			require.NoError(t, proof.Put(Keccak256(node), node))
+		}
+		require.NoError(t, proof.Delete(Keccak256([]byte{2})))
+		require.NoError(t, proof.Put(Keccak256([]byte{1}), []byte{1}))
+		require.Equal(t, [][]byte{{1}, {3}}, proof.Serialize())
+	})
+}
+
+func TestProveHashes(t *testing.T) {
+	tr := NewTrie()
+	tr.Put([]byte{1, 2, 3}, []byte("hello"))
+	tr.Put([]byte{1, 2, 3, 4, 5}, []byte("world"))
+
+	t.Run("should return the hashes of the proof nodes, from the root", func(t *testing.T) {
+		hashes, ok := tr.ProveHashes([]byte{1, 2, 3})
+		require.True(t, ok)
+		require.Len(t, hashes, 2)
+		require.Equal(t, tr.Hash(), hashes[0])
+
+		proof, ok := tr.Prove([]byte{1, 2, 3})
+		require.True(t, ok)
+		for _, hash := range hashes {
+			has, err := proof.Has(hash)
+			require.NoError(t, err)
+			require.True(t, has)
+		}
+	})
+
+	t.Run("should not return hashes for non-exist key", func(t *testing.T) {
+		_, ok := tr.ProveHashes([]byte{1, 2, 3, 4})
+		require.False(t, ok)
+	})
+
+	t.Run("should differ if the trie was updated", func(t *testing.T) {
+		hashes, _ := tr.ProveHashes([]byte{1, 2, 3})
+		tr.Put([]byte{1, 2, 3, 4, 5}, []byte("trie"))
+		updated, _ := tr.ProveHashes([]byte{1, 2, 3})
+		require.NotEqual(t, hashes, updated)
+	})
+}
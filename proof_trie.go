@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// ErrNotCovered is returned when looking up a key in a trie created by
+// NewTrieFromProof, if the nodes needed for the key were not in the proof.
+var ErrNotCovered = errors.New("key not covered by the proof")
+
+// recoverNotCovered must be deferred, it recovers from a panic with
+// ErrNotCovered by setting err to it, and panics again with any other value.
+func recoverNotCovered(err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if r != ErrNotCovered {
+		panic(r)
+	}
+	*err = ErrNotCovered
+}
+
+// NewTrieFromProof creates a trie from the nodes of a proof under the given
+// root hash. Only the nodes reachable from the root are used, and the nodes
+// missing from the proof are kept as HashNodes, so the trie has the same hash
+// as the original one.
+// Keys covered by the proof can be looked up with TryGet and proven with Prove,
+// for the other keys TryGet returns ErrNotCovered.
+// Each proof node is resolved at most once: a proof whose nodes are referenced
+// several times, which makes the trie grow exponentially with its depth, is
+// rejected.
+func NewTrieFromProof(rootHash []byte, proof Proof) (*Trie, error) {
+	if bytes.Equal(rootHash, EmptyNodeHash) {
+		return NewTrie(), nil
+	}
+
+	remaining := len(proof.Serialize())
+	root, err := resolveProofNode(HashNode(rootHash), proof, &remaining)
+	if err != nil {
+		return nil, fmt.Errorf("could not build trie from proof: %w", err)
+	}
+
+	if _, ok := root.(HashNode); ok {
+		return nil, fmt.Errorf("proof does not contain the root node %x", rootHash)
+	}
+
+	return &Trie{root: root}, nil
+}
+
+// resolveProofNode replaces the hash nodes found in the proof with the decoded
+// nodes, recursively. remaining is the number of proof nodes that can still be
+// resolved, it fails once more nodes are resolved than the proof has.
+func resolveProofNode(node Node, proof Proof, remaining *int) (Node, error) {
+	if hash, ok := node.(HashNode); ok {
+		has, err := proof.Has(hash)
+		if err != nil {
+			return nil, fmt.Errorf("could not look up proof node %x: %w", []byte(hash), err)
+		}
+		if !has {
+			return node, nil
+		}
+
+		if *remaining == 0 {
+			return nil, fmt.Errorf("proof node %x is referenced more than once", []byte(hash))
+		}
+		*remaining--
+
+		data, err := proof.Get(hash)
+		if err != nil {
+			return nil, fmt.Errorf("could not get proof node %x: %w", []byte(hash), err)
+		}
+		if !bytes.Equal(Keccak256(data), hash) {
+			return nil, fmt.Errorf("proof node does not match hash %x", []byte(hash))
+		}
+
+		node, err = DecodeNode(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proof node %x: %w", []byte(hash), err)
+		}
+	}
+
+	if branch, ok := node.(*BranchNode); ok {
+		for i, child := range branch.Branches {
+			if IsEmptyNode(child) {
+				continue
+			}
+			resolved, err := resolveProofNode(child, proof, remaining)
+			if err != nil {
+				return nil, err
+			}
+			branch.SetBranch(Nibble(i), resolved)
+		}
+	}
+
+	if ext, ok := node.(*ExtensionNode); ok {
+		resolved, err := resolveProofNode(ext.Next, proof, remaining)
+		if err != nil {
+			return nil, err
+		}
+		ext.Next = resolved
+	}
+
+	return node, nil
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTrieFromProof(t *testing.T) {
+	tr := newTestTrie(t, 50)
+	rootHash := tr.Hash()
+
+	key := testKey(7)
+	proof, ok := tr.Prove(key)
+	require.True(t, ok)
+
+	partial, err := NewTrieFromProof(rootHash, proof)
+	require.NoError(t, err)
+
+	t.Run("should have the same root hash", func(t *testing.T) {
+		require.Equal(t, rootHash, partial.Hash())
+	})
+
+	t.Run("should get the value of a covered key", func(t *testing.T) {
+		val, found, err := partial.TryGet(key)
+		require.NoError(t, err)
+		require.True(t, found)
+		require.Equal(t, []byte("value7"), val)
+	})
+
+	t.Run("should return ErrNotCovered for other keys", func(t *testing.T) {
+		_, _, err := partial.TryGet([]byte("key30"))
+		require.True(t, errors.Is(err, ErrNotCovered))
+
+		_, found := partial.Get([]byte("key30"))
+		require.False(t, found)
+	})
+
+	t.Run("should return ErrNotCovered when updating other keys", func(t *testing.T) {
+		updated, err := NewTrieFromProof(rootHash, proof)
+		require.NoError(t, err)
+
+		require.Equal(t, ErrNotCovered, updated.TryPut([]byte("key30"), []byte("value")))
+		deleted, err := updated.TryDelete([]byte("key30"))
+		require.Equal(t, ErrNotCovered, err)
+		require.False(t, deleted)
+		require.Equal(t, rootHash, updated.Hash())
+
+		require.NoError(t, updated.TryPut(key, []byte("updated")))
+		val, found := updated.Get(key)
+		require.True(t, found)
+		require.Equal(t, []byte("updated"), val)
+
+		deleted, err = updated.TryDelete(key)
+		require.NoError(t, err)
+		require.True(t, deleted)
+	})
+
+	t.Run("should leave the trie unchanged when a delete is not covered", func(t *testing.T) {
+		tr := NewTrie()
+		tr.Put([]byte{1, 2, 3, 4}, bytes.Repeat([]byte{1}, 40))
+		tr.Put([]byte{1, 2, 3, 5}, bytes.Repeat([]byte{2}, 40))
+		proof, ok := tr.Prove([]byte{1, 2, 3, 4})
+		require.True(t, ok)
+		partial, err := NewTrieFromProof(tr.Hash(), proof)
+		require.NoError(t, err)
+
+		// the remaining sibling is only known by its hash, so the branch can't
+		// be collapsed into it
+		require.PanicsWithValue(t, ErrNotCovered, func() {
+			partial.Delete([]byte{1, 2, 3, 4})
+		})
+		require.Equal(t, tr.Hash(), partial.Hash())
+		deleted, err := partial.TryDelete([]byte{1, 2, 3, 4})
+		require.Equal(t, ErrNotCovered, err)
+		require.False(t, deleted)
+		require.Equal(t, tr.Hash(), partial.Hash())
+		val, found := partial.Get([]byte{1, 2, 3, 4})
+		require.True(t, found)
+		require.Equal(t, bytes.Repeat([]byte{1}, 40), val)
+	})
+
+	t.Run("should not prove keys or paths that are not covered", func(t *testing.T) {
+		proof, ok := partial.Prove([]byte("key30"))
+		require.False(t, ok)
+		require.Nil(t, proof)
+
+		proof, ok, err := partial.TryProve([]byte("key30"))
+		require.True(t, errors.Is(err, ErrNotCovered))
+		require.False(t, ok)
+		require.Nil(t, proof)
+
+		pathProof, hash, ok := partial.ProvePath(FromBytes([]byte("key30")))
+		require.False(t, ok)
+		require.Nil(t, pathProof)
+		require.Nil(t, hash)
+	})
+
+	t.Run("should prove a covered key", func(t *testing.T) {
+		reproof, ok, err := partial.TryProve(key)
+		require.NoError(t, err)
+		require.True(t, ok)
+		val, err := VerifyProof(rootHash, key, reproof)
+		require.NoError(t, err)
+		require.Equal(t, []byte("value7"), val)
+	})
+
+	t.Run("should fail if the proof is for another root", func(t *testing.T) {
+		other := NewTrie()
+		other.Put(testKey(7), testValue(7))
+		_, err := NewTrieFromProof(other.Hash(), proof)
+		require.Error(t, err)
+	})
+
+	t.Run("should fail if a node doesn't match its hash", func(t *testing.T) {
+		tampered := NewProofDB()
+		for _, node := range proof.Serialize() {
+			tampered.Put(Keccak256(node), node)
+		}
+		tampered.Put(rootHash, []byte{0xc0})
+		_, err := NewTrieFromProof(rootHash, tampered)
+		require.Error(t, err)
+	})
+
+	t.Run("should create an empty trie from the empty root", func(t *testing.T) {
+		empty, err := NewTrieFromProof(EmptyNodeHash, NewProofDB())
+		require.NoError(t, err)
+		require.Equal(t, EmptyNodeHash, empty.Hash())
+	})
+
+	t.Run("should reject a proof whose nodes are referenced several times", func(t *testing.T) {
+		rootHash, proof := newDAGProof(8)
+		_, err := NewTrieFromProof(rootHash, proof)
+		require.Error(t, err)
+	})
+}
+
+// newDAGProof returns a proof of depth branch nodes above a leaf, where each
+// branch node references the node below it from all its 16 children, so that
+// resolving it as a tree decodes 16^depth nodes.
+func newDAGProof(depth int) ([]byte, *ProofDB) {
+	proof := NewProofDB()
+	var node Node = NewLeafNodeFromNibbles([]Nibble{1}, bytes.Repeat([]byte{1}, 40))
+	proof.Put(node.Hash(), Serialize(node))
+	for i := 0; i < depth; i++ {
+		branch := NewBranchNode()
+		for b := 0; b < 16; b++ {
+			branch.SetBranch(Nibble(b), HashNode(node.Hash()))
+		}
+		node = branch
+		proof.Put(node.Hash(), Serialize(node))
+	}
+	return node.Hash(), proof
+}
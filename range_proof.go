@@ -0,0 +1,272 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// RangeProof proves the key value pairs of a contiguous range of keys: the
+// values between the first and the last key, and the proofs of the paths to
+// both ends of the range, which show that no key was left out.
+type RangeProof struct {
+	// FirstKey is the start of the range, which doesn't need to exist
+	FirstKey []byte
+	// Keys are the keys in the range, in increasing order
+	Keys [][]byte
+	// Values are the values of the keys, as stored in the trie
+	Values [][]byte
+	// Proof contains the nodes on the paths to FirstKey and to the last key
+	Proof Proof
+}
+
+// ProveRange returns the proof of the keys from firstKey to lastKey included.
+// All the keys of the range must have the same length as firstKey and lastKey,
+// like in the Ethereum state trie where keys are hashes.
+// It returns false if there is no key in the range, if a key of the range has
+// a different length, or if the range reaches nodes only known by their hash.
+func (t *Trie) ProveRange(firstKey []byte, lastKey []byte) (*RangeProof, bool) {
+	if len(firstKey) != len(lastKey) {
+		return nil, false
+	}
+
+	keys := make([][]byte, 0)
+	values := make([][]byte, 0)
+
+	it := &Iterator{trie: t, raw: true}
+	it.Seek(firstKey)
+	for it.Next() {
+		if bytes.Compare(it.Key(), lastKey) > 0 {
+			break
+		}
+		if len(it.Key()) != len(firstKey) {
+			return nil, false
+		}
+		keys = append(keys, it.Key())
+		values = append(values, it.Value())
+	}
+	if it.Err() != nil || len(keys) == 0 {
+		return nil, false
+	}
+
+	proof := NewProofDB()
+	for _, key := range [][]byte{firstKey, keys[len(keys)-1]} {
+		nodes, _ := t.pathNodes(key)
+		for _, node := range nodes {
+			proof.Put(Hash(node), Serialize(node))
+		}
+	}
+
+	return &RangeProof{
+		FirstKey: firstKey,
+		Keys:     keys,
+		Values:   values,
+		Proof:    proof,
+	}, true
+}
+
+// VerifyRangeProof verifies the range proof under the given root hash, and
+// returns whether the trie has more keys after the range.
+// The trie is rebuilt from the proof, with the keys from FirstKey to the last
+// key removed, and the keys of the range are put back: the root hash only
+// matches if no key was added, removed or modified.
+func VerifyRangeProof(rootHash []byte, proof *RangeProof) (bool, error) {
+	if len(proof.Keys) == 0 || len(proof.Keys) != len(proof.Values) {
+		return false, fmt.Errorf("range proof has %v keys and %v values", len(proof.Keys), len(proof.Values))
+	}
+
+	previous := proof.FirstKey
+	for i, key := range proof.Keys {
+		if len(key) != len(proof.FirstKey) {
+			return false, fmt.Errorf("key %x does not have the length of the first key %x", key, proof.FirstKey)
+		}
+		if (i == 0 && bytes.Compare(previous, key) > 0) || (i > 0 && bytes.Compare(previous, key) >= 0) {
+			return false, fmt.Errorf("key %x is out of order", key)
+		}
+		if len(proof.Values[i]) == 0 {
+			return false, fmt.Errorf("key %x has an empty value", key)
+		}
+		previous = key
+	}
+
+	trie, err := NewTrieFromProof(rootHash, proof.Proof)
+	if err != nil {
+		return false, err
+	}
+
+	right := FromBytes(proof.Keys[len(proof.Keys)-1])
+	root, err := unsetRange(trie.root, FromBytes(proof.FirstKey), right, true, true)
+	if err != nil {
+		return false, err
+	}
+	hasMore := hasKeysAfter(trie.root, right)
+
+	trie.root = root
+	for i, key := range proof.Keys {
+		trie.put(key, proof.Values[i])
+	}
+
+	if !bytes.Equal(trie.Hash(), rootHash) {
+		return false, fmt.Errorf("range does not match the root hash %x", rootHash)
+	}
+	return hasMore, nil
+}
+
+// unsetRange returns the node without the keys from left to right included,
+// both bounds being relative to the node. The range is open on the side of a
+// bound that is not set. The nodes on the paths to the bounds are copied, and
+// must be known, the other nodes are kept as is.
+func unsetRange(node Node, left []Nibble, right []Nibble, hasLeft bool, hasRight bool) (Node, error) {
+	if IsEmptyNode(node) || (!hasLeft && !hasRight) {
+		return nil, nil
+	}
+
+	if leaf, ok := node.(*LeafNode); ok {
+		if hasLeft && compareNibbles(leaf.Path, left) < 0 {
+			return leaf, nil
+		}
+		if hasRight && compareNibbles(leaf.Path, right) > 0 {
+			return leaf, nil
+		}
+		return nil, nil
+	}
+
+	if branch, ok := node.(*BranchNode); ok {
+		updated := *branch
+		// the key of the value is the smallest key of the branch
+		if !hasLeft || len(left) == 0 {
+			updated.RemoveValue()
+		}
+
+		for i, child := range branch.Branches {
+			if IsEmptyNode(child) {
+				continue
+			}
+
+			childLeft, childHasLeft := left, hasLeft
+			if hasLeft && len(left) > 0 && i < int(left[0]) {
+				continue
+			} else if hasLeft && len(left) > 0 && i == int(left[0]) {
+				childLeft = left[1:]
+			} else {
+				childHasLeft = false
+			}
+
+			childRight, childHasRight := right, hasRight
+			if hasRight && (len(right) == 0 || i > int(right[0])) {
+				continue
+			} else if hasRight && i == int(right[0]) {
+				childRight = right[1:]
+			} else {
+				childHasRight = false
+			}
+
+			unset, err := unsetRange(child, childLeft, childRight, childHasLeft, childHasRight)
+			if err != nil {
+				return nil, err
+			}
+			updated.SetBranch(Nibble(i), unset)
+		}
+
+		for _, child := range updated.Branches {
+			if !IsEmptyNode(child) {
+				return &updated, nil
+			}
+		}
+		if updated.HasValue() {
+			return &updated, nil
+		}
+		return nil, nil
+	}
+
+	if ext, ok := node.(*ExtensionNode); ok {
+		if hasLeft {
+			cmp := compareNibbles(ext.Path, nibblesPrefix(left, len(ext.Path)))
+			if cmp < 0 {
+				return ext, nil
+			} else if cmp > 0 {
+				hasLeft = false
+			} else {
+				left = left[len(ext.Path):]
+			}
+		}
+
+		if hasRight {
+			cmp := compareNibbles(ext.Path, nibblesPrefix(right, len(ext.Path)))
+			if cmp > 0 {
+				return ext, nil
+			} else if cmp < 0 {
+				hasRight = false
+			} else {
+				right = right[len(ext.Path):]
+			}
+		}
+
+		next, err := unsetRange(ext.Next, left, right, hasLeft, hasRight)
+		if err != nil {
+			return nil, err
+		}
+		if IsEmptyNode(next) {
+			return nil, nil
+		}
+		return NewExtensionNode(ext.Path, next), nil
+	}
+
+	if hash, ok := node.(HashNode); ok {
+		return nil, fmt.Errorf("proof does not contain the node %x at the edge of the range", []byte(hash))
+	}
+
+	panic("unknown type")
+}
+
+// hasKeysAfter returns whether the node has keys after the bound, which is
+// relative to the node.
+func hasKeysAfter(node Node, bound []Nibble) bool {
+	if IsEmptyNode(node) {
+		return false
+	}
+
+	if leaf, ok := node.(*LeafNode); ok {
+		return compareNibbles(leaf.Path, bound) > 0
+	}
+
+	if branch, ok := node.(*BranchNode); ok {
+		if len(bound) == 0 {
+			for _, child := range branch.Branches {
+				if !IsEmptyNode(child) {
+					return true
+				}
+			}
+			return false
+		}
+
+		for i := int(bound[0]) + 1; i < 16; i++ {
+			if !IsEmptyNode(branch.Branches[i]) {
+				return true
+			}
+		}
+		return hasKeysAfter(branch.Branches[bound[0]], bound[1:])
+	}
+
+	if ext, ok := node.(*ExtensionNode); ok {
+		cmp := compareNibbles(ext.Path, nibblesPrefix(bound, len(ext.Path)))
+		if cmp != 0 {
+			return cmp > 0
+		}
+		return hasKeysAfter(ext.Next, bound[len(ext.Path):])
+	}
+
+	if _, ok := node.(HashNode); ok {
+		// unsetRange fails before if a node on the path is unknown
+		return true
+	}
+
+	panic("unknown type")
+}
+
+// nibblesPrefix returns the first n nibbles, or all of them if there are fewer.
+func nibblesPrefix(nibbles []Nibble, n int) []Nibble {
+	if len(nibbles) < n {
+		return nibbles
+	}
+	return nibbles[:n]
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"sort"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRangeProof(t *testing.T) {
+	tr := NewTrie()
+	keys := make([][]byte, 0)
+	for i := 0; i < 100; i++ {
+		key := sha256.Sum256([]byte{byte(i)})
+		keys = append(keys, key[:])
+		tr.Put(key[:], []byte{byte(i)})
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return bytes.Compare(keys[i], keys[j]) < 0
+	})
+
+	t.Run("should verify the keys of a range", func(t *testing.T) {
+		proof, ok := tr.ProveRange(keys[10], keys[20])
+		require.True(t, ok)
+		require.Equal(t, keys[10:21], proof.Keys)
+
+		hasMore, err := VerifyRangeProof(tr.Hash(), proof)
+		require.NoError(t, err)
+		require.True(t, hasMore)
+	})
+
+	t.Run("should verify a range starting at a non-exist key", func(t *testing.T) {
+		firstKey := append([]byte{}, keys[10]...)
+		firstKey[31]++
+		proof, ok := tr.ProveRange(firstKey, keys[20])
+		require.True(t, ok)
+		require.Equal(t, keys[11:21], proof.Keys)
+
+		_, err := VerifyRangeProof(tr.Hash(), proof)
+		require.NoError(t, err)
+	})
+
+	t.Run("should verify the range up to the last key", func(t *testing.T) {
+		proof, ok := tr.ProveRange(keys[90], bytes.Repeat([]byte{0xff}, 32))
+		require.True(t, ok)
+		require.Len(t, proof.Keys, 10)
+
+		hasMore, err := VerifyRangeProof(tr.Hash(), proof)
+		require.NoError(t, err)
+		require.False(t, hasMore)
+	})
+
+	t.Run("should not prove an empty range", func(t *testing.T) {
+		firstKey := append([]byte{}, keys[10]...)
+		firstKey[31]++
+		_, ok := tr.ProveRange(firstKey, firstKey)
+		require.False(t, ok)
+	})
+
+	t.Run("should fail if a key is missing or a value is modified", func(t *testing.T) {
+		proof, ok := tr.ProveRange(keys[10], keys[20])
+		require.True(t, ok)
+
+		proof.Values[5] = []byte{0xff}
+		_, err := VerifyRangeProof(tr.Hash(), proof)
+		require.Error(t, err)
+
+		proof, _ = tr.ProveRange(keys[10], keys[20])
+		proof.Keys = append(proof.Keys[:5], proof.Keys[6:]...)
+		proof.Values = append(proof.Values[:5], proof.Values[6:]...)
+		_, err = VerifyRangeProof(tr.Hash(), proof)
+		require.Error(t, err)
+	})
+
+	t.Run("should fail if a key is added or the first key is moved", func(t *testing.T) {
+		proof, ok := tr.ProveRange(keys[10], keys[20])
+		require.True(t, ok)
+		extra := append([]byte{}, keys[15]...)
+		extra[31]++
+		proof.Keys = append(proof.Keys[:6], append([][]byte{extra}, proof.Keys[6:]...)...)
+		proof.Values = append(proof.Values[:6], append([][]byte{{0x01}}, proof.Values[6:]...)...)
+		_, err := VerifyRangeProof(tr.Hash(), proof)
+		require.Error(t, err)
+
+		proof, _ = tr.ProveRange(keys[10], keys[20])
+		proof.FirstKey = keys[11]
+		_, err = VerifyRangeProof(tr.Hash(), proof)
+		require.Error(t, err)
+	})
+
+	t.Run("should not prove keys of a different length", func(t *testing.T) {
+		_, ok := tr.ProveRange(keys[10][:31], keys[20][:31])
+		require.False(t, ok)
+		_, ok = tr.ProveRange(keys[10], keys[20][:31])
+		require.False(t, ok)
+
+		mixed := NewTrie()
+		mixed.Put([]byte{1, 2}, []byte("a"))
+		mixed.Put([]byte{1, 2, 3}, []byte("b"))
+		mixed.Put([]byte{1, 3}, []byte("c"))
+		_, ok = mixed.ProveRange([]byte{1, 0}, []byte{1, 3})
+		require.False(t, ok)
+
+		proof, ok := tr.ProveRange(keys[10], keys[20])
+		require.True(t, ok)
+		proof.Keys[3] = proof.Keys[3][:31]
+		_, err := VerifyRangeProof(tr.Hash(), proof)
+		require.Error(t, err)
+	})
+
+	t.Run("should reject a proof whose nodes are referenced several times", func(t *testing.T) {
+		rootHash, dag := newDAGProof(8)
+		_, err := VerifyRangeProof(rootHash, &RangeProof{
+			FirstKey: []byte{0},
+			Keys:     [][]byte{{1}},
+			Values:   [][]byte{{1}},
+			Proof:    dag,
+		})
+		require.Error(t, err)
+	})
+}
+
+func TestVerifyRangeProofMatchesEth(t *testing.T) {
+	// small values, so that some nodes are embedded in their parent
+	tr := NewTrie()
+	keys := make([][]byte, 0)
+	for i := 0; i < 40; i++ {
+		key := Keccak256([]byte{byte(i)})[:2]
+		keys = append(keys, key)
+		tr.Put(key, []byte{byte(i)})
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return bytes.Compare(keys[i], keys[j]) < 0
+	})
+	rootHash := tr.Hash()
+
+	t.Run("should return the same result as go-ethereum's VerifyRangeProof", func(t *testing.T) {
+		for i := range keys {
+			for j := i; j < len(keys); j++ {
+				proof, ok := tr.ProveRange(keys[i], keys[j])
+				require.True(t, ok)
+
+				hasMore, err := VerifyRangeProof(rootHash, proof)
+				require.NoError(t, err)
+
+				expectedErr, expected := trie.VerifyRangeProof(common.BytesToHash(rootHash),
+					proof.FirstKey, proof.Keys, proof.Values, proof.Proof, proof.Proof)
+				require.NoError(t, expectedErr)
+				require.Equal(t, expected, hasMore)
+				require.Equal(t, j < len(keys)-1, hasMore)
+			}
+		}
+	})
+
+	t.Run("should verify ranges starting before the first key", func(t *testing.T) {
+		for j := range keys {
+			proof, ok := tr.ProveRange([]byte{0, 0}, keys[j])
+			require.True(t, ok)
+			require.Equal(t, keys[:j+1], proof.Keys)
+
+			hasMore, err := VerifyRangeProof(rootHash, proof)
+			require.NoError(t, err)
+			require.Equal(t, j < len(keys)-1, hasMore)
+		}
+	})
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// EncodeRecord encodes the fields of a record into a single value, the RLP list
+// of the fields, so that records with several fields take a single leaf.
+func EncodeRecord(fields [][]byte) []byte {
+	value, err := rlp.EncodeToBytes(fields)
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
+
+// DecodeRecord decodes a value encoded by EncodeRecord into its fields.
+func DecodeRecord(value []byte) ([][]byte, error) {
+	var fields [][]byte
+	err := rlp.DecodeBytes(value, &fields)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode record: %w", err)
+	}
+	return fields, nil
+}
+
+// GetField returns the field at the given index of the record stored under the key.
+// A missing record or a field index beyond the record's fields is not found.
+func (t *Trie) GetField(key []byte, index int) ([]byte, bool, error) {
+	value, found := t.Get(key)
+	if !found {
+		return nil, false, nil
+	}
+
+	fields, err := DecodeRecord(value)
+	if err != nil {
+		return nil, false, fmt.Errorf("could not get field %v of key %x: %w", index, key, err)
+	}
+
+	if index < 0 || index >= len(fields) {
+		return nil, false, nil
+	}
+	return fields[index], true, nil
+}
+
+// UpdateFields sets the given fields, by index, of the record stored under the
+// key, keeping the other fields, and writes the record back with a single Put.
+// A missing record is created, and the fields before the highest updated index
+// that don't exist yet are left empty.
+func (t *Trie) UpdateFields(key []byte, updates map[int][]byte) error {
+	fields := make([][]byte, 0)
+	value, found := t.Get(key)
+	if found {
+		decoded, err := DecodeRecord(value)
+		if err != nil {
+			return fmt.Errorf("could not update fields of key %x: %w", key, err)
+		}
+		fields = decoded
+	}
+
+	for index, field := range updates {
+		if index < 0 {
+			return fmt.Errorf("could not update fields of key %x: negative index %v", key, index)
+		}
+		for len(fields) <= index {
+			fields = append(fields, []byte{})
+		}
+		fields[index] = field
+	}
+
+	t.Put(key, EncodeRecord(fields))
+	return nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecord(t *testing.T) {
+	t.Run("should decode encoded fields", func(t *testing.T) {
+		fields := [][]byte{[]byte("alice"), {}, {0x01, 0x02}}
+		decoded, err := DecodeRecord(EncodeRecord(fields))
+		require.NoError(t, err)
+		require.Equal(t, fields, decoded)
+
+		_, err = DecodeRecord([]byte("not a list"))
+		require.Error(t, err)
+	})
+
+	t.Run("should update some fields and keep the others", func(t *testing.T) {
+		tr := NewTrie()
+		key := []byte{1, 2, 3}
+		require.NoError(t, tr.UpdateFields(key, map[int][]byte{0: []byte("alice"), 1: []byte("100")}))
+		require.NoError(t, tr.UpdateFields(key, map[int][]byte{1: []byte("90"), 3: []byte("bob")}))
+
+		field, found, err := tr.GetField(key, 0)
+		require.NoError(t, err)
+		require.True(t, found)
+		require.Equal(t, []byte("alice"), field)
+
+		field, found, err = tr.GetField(key, 1)
+		require.NoError(t, err)
+		require.True(t, found)
+		require.Equal(t, []byte("90"), field)
+
+		field, found, err = tr.GetField(key, 2)
+		require.NoError(t, err)
+		require.True(t, found)
+		require.Equal(t, []byte{}, field)
+
+		_, found, err = tr.GetField(key, 4)
+		require.NoError(t, err)
+		require.False(t, found)
+
+		expected := NewTrie()
+		expected.Put(key, EncodeRecord([][]byte{[]byte("alice"), []byte("90"), {}, []byte("bob")}))
+		require.Equal(t, expected.Hash(), tr.Hash())
+	})
+
+	t.Run("should fail on values that are not records", func(t *testing.T) {
+		tr := NewTrie()
+		tr.Put([]byte{1}, []byte("hello"))
+		_, _, err := tr.GetField([]byte{1}, 0)
+		require.Error(t, err)
+		require.Error(t, tr.UpdateFields([]byte{1}, map[int][]byte{0: []byte("world")}))
+	})
+}
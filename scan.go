@@ -0,0 +1,79 @@
+package main
+
+// KeyValue is a key value pair of a trie.
+type KeyValue struct {
+	Key   []byte
+	Value []byte
+}
+
+// Scan returns the key value pairs whose keys start with the given prefix, in
+// key order. Only the subtrie under the prefix is walked.
+// It returns ErrNotCovered if the subtrie contains nodes only known by their hash.
+func (t *Trie) Scan(prefix []byte) ([]KeyValue, error) {
+	it := &Iterator{trie: t}
+	node, path, err := t.subtrie(FromBytes(prefix))
+	if err != nil {
+		return nil, err
+	}
+	if !IsEmptyNode(node) {
+		it.stack = append(it.stack, iteratorItem{node: node, path: path})
+	}
+
+	pairs := make([]KeyValue, 0)
+	for it.Next() {
+		pairs = append(pairs, KeyValue{Key: it.Key(), Value: it.Value()})
+	}
+	if it.Err() != nil {
+		return nil, it.Err()
+	}
+	return pairs, nil
+}
+
+// subtrie returns the highest node whose keys all start with the given nibbles,
+// and the path to it, which the nibbles are a prefix of.
+func (t *Trie) subtrie(nibbles []Nibble) (Node, []Nibble, error) {
+	node := t.root
+	path := []Nibble{}
+	for {
+		if IsEmptyNode(node) || len(nibbles) == 0 {
+			return node, path, nil
+		}
+
+		if leaf, ok := node.(*LeafNode); ok {
+			matched := PrefixMatchedLen(leaf.Path, nibbles)
+			if matched < len(nibbles) {
+				return nil, nil, nil
+			}
+			return leaf, path, nil
+		}
+
+		if branch, ok := node.(*BranchNode); ok {
+			b, remaining := nibbles[0], nibbles[1:]
+			nibbles = remaining
+			path = appendNibbles(path, b)
+			node = branch.Branches[b]
+			continue
+		}
+
+		if ext, ok := node.(*ExtensionNode); ok {
+			matched := PrefixMatchedLen(ext.Path, nibbles)
+			if matched == len(nibbles) {
+				return ext, path, nil
+			}
+			if matched < len(ext.Path) {
+				return nil, nil, nil
+			}
+
+			nibbles = nibbles[matched:]
+			path = appendNibbles(path, ext.Path...)
+			node = ext.Next
+			continue
+		}
+
+		if _, ok := node.(HashNode); ok {
+			return nil, nil, ErrNotCovered
+		}
+
+		panic("unknown type")
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScan(t *testing.T) {
+	t.Run("should return the pairs under a namespace", func(t *testing.T) {
+		tr := NewTrie()
+		tr.Put([]byte("alice/balance"), []byte("10"))
+		tr.Put([]byte("alice/nonce"), []byte("1"))
+		tr.Put([]byte("alice"), []byte("account"))
+		tr.Put([]byte("bob/balance"), []byte("20"))
+
+		pairs, err := tr.Scan([]byte("alice/"))
+		require.NoError(t, err)
+		require.Equal(t, []KeyValue{
+			{Key: []byte("alice/balance"), Value: []byte("10")},
+			{Key: []byte("alice/nonce"), Value: []byte("1")},
+		}, pairs)
+
+		pairs, err = tr.Scan([]byte("carol"))
+		require.NoError(t, err)
+		require.Empty(t, pairs)
+
+		pairs, err = tr.Scan([]byte{})
+		require.NoError(t, err)
+		require.Len(t, pairs, 4)
+	})
+
+	t.Run("should match a full scan filtered by prefix", func(t *testing.T) {
+		tr := NewTrie()
+		r := rand.New(rand.NewSource(1))
+		for i := 0; i < 300; i++ {
+			key := make([]byte, r.Intn(4))
+			r.Read(key)
+			tr.Put(key, []byte(fmt.Sprintf("value%v", i)))
+		}
+		all, err := tr.Scan([]byte{})
+		require.NoError(t, err)
+
+		for i := 0; i < 100; i++ {
+			prefix := make([]byte, r.Intn(3))
+			r.Read(prefix)
+			if i%2 == 0 && len(prefix) > 0 {
+				// prefixes of existing keys
+				key := all[r.Intn(len(all))].Key
+				prefix = key[:r.Intn(len(key)+1)]
+			}
+
+			expected := make([]KeyValue, 0)
+			for _, pair := range all {
+				if bytes.HasPrefix(pair.Key, prefix) {
+					expected = append(expected, pair)
+				}
+			}
+
+			pairs, err := tr.Scan(prefix)
+			require.NoError(t, err)
+			require.Equal(t, expected, pairs, "prefix %x", prefix)
+		}
+	})
+}
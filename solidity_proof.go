@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// SolidityProof is a proof laid out the way the common Solidity MPT verifier
+// libraries take it, so that it can be submitted on-chain as is. It encodes to
+// JSON with hex strings, ready to be passed to a contract call.
+type SolidityProof struct {
+	Root hexutil.Bytes `json:"root"`
+	// Path is the key in hex-prefix encoding, with the even length extension
+	// flag, which is the encoded path verifiers expect
+	Path  hexutil.Bytes `json:"path"`
+	Value hexutil.Bytes `json:"value"`
+	// Nodes are the serialized proof nodes from the root down, not including
+	// the nodes embedded in their parent, for verifiers taking a bytes[]
+	Nodes []hexutil.Bytes `json:"nodes"`
+	// ParentNodes is the RLP list of the nodes, for verifiers taking the whole
+	// proof as a single bytes
+	ParentNodes hexutil.Bytes `json:"parentNodes"`
+}
+
+// NewSolidityProof converts the proof for the given key under the given root
+// hash, as returned by Prove, into a SolidityProof. The proof is verified
+// while being converted, and an error is returned if it's invalid or if it
+// doesn't prove a value for the key.
+func NewSolidityProof(rootHash []byte, key []byte, proof Proof) (*SolidityProof, error) {
+	nodes, value, err := walkProof(rootHash, key, proof)
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return nil, fmt.Errorf("key %x is not in the trie", key)
+	}
+
+	raws := make([]rlp.RawValue, 0, len(nodes))
+	hexNodes := make([]hexutil.Bytes, 0, len(nodes))
+	for _, node := range nodes {
+		raws = append(raws, node)
+		hexNodes = append(hexNodes, node)
+	}
+	parentNodes, err := rlp.EncodeToBytes(raws)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode proof nodes: %w", err)
+	}
+
+	return &SolidityProof{
+		Root:        rootHash,
+		Path:        ToBytes(ToPrefixed(FromBytes(key), false)),
+		Value:       value,
+		Nodes:       hexNodes,
+		ParentNodes: parentNodes,
+	}, nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSolidityProof(t *testing.T) {
+	tr := newTestTrie(t, 100)
+	key := testKey(42)
+	proof, ok := tr.Prove(key)
+	require.True(t, ok)
+
+	t.Run("should lay out the proof nodes from the root", func(t *testing.T) {
+		solidityProof, err := NewSolidityProof(tr.Hash(), key, proof)
+		require.NoError(t, err)
+
+		require.Equal(t, tr.Hash(), []byte(solidityProof.Root))
+		require.Equal(t, append([]byte{0}, key...), []byte(solidityProof.Path))
+		require.Equal(t, []byte("value42"), []byte(solidityProof.Value))
+		require.Equal(t, tr.Hash(), Keccak256(solidityProof.Nodes[0]))
+
+		var parentNodes []rlp.RawValue
+		require.NoError(t, rlp.DecodeBytes(solidityProof.ParentNodes, &parentNodes))
+		require.Len(t, parentNodes, len(solidityProof.Nodes))
+		for i, node := range parentNodes {
+			require.Equal(t, []byte(solidityProof.Nodes[i]), []byte(node))
+		}
+	})
+
+	t.Run("should encode to JSON with hex strings", func(t *testing.T) {
+		solidityProof, err := NewSolidityProof(tr.Hash(), key, proof)
+		require.NoError(t, err)
+
+		data, err := json.Marshal(solidityProof)
+		require.NoError(t, err)
+		require.Contains(t, string(data), fmt.Sprintf(`"path":"0x00%x"`, key))
+	})
+
+	t.Run("should fail for a key not proven by the proof", func(t *testing.T) {
+		_, err := NewSolidityProof(tr.Hash(), []byte("key4200"), proof)
+		require.Error(t, err)
+
+		_, err = NewSolidityProof(Keccak256([]byte("other root")), key, proof)
+		require.Error(t, err)
+	})
+}
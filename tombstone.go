@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+)
+
+// Tombstone is the value Delete stores in tombstone mode. It is reserved:
+// in tombstone mode, a key with this value is considered deleted.
+var Tombstone = Keccak256([]byte("merkle-patricia-trie tombstone"))
+
+// ErrTombstoneValue is the panic of Put in tombstone mode for a value that
+// would be stored as Tombstone, and so be taken for a deleted key.
+var ErrTombstoneValue = errors.New("value is reserved for tombstones")
+
+// EnableTombstones switches the trie to tombstone mode, where Delete replaces
+// the value with Tombstone instead of removing the key. A deletion then
+// changes a single leaf, the same way a Put does, so it can be proven like one.
+// Get, Iterator and Scan skip the tombstones, and Compact removes them.
+func (t *Trie) EnableTombstones() {
+	t.tombstones = true
+}
+
+func (t *Trie) isTombstone(stored []byte) bool {
+	return t.tombstones && bytes.Equal(stored, Tombstone)
+}
+
+// checkNotTombstone panics with ErrTombstoneValue in tombstone mode if either
+// the value given to Put or the value stored in the leaf for it is Tombstone.
+// With value commitments, the stored value is a hash, which is Tombstone for
+// the value Tombstone was hashed from.
+func (t *Trie) checkNotTombstone(value []byte, stored []byte) {
+	if t.tombstones && (bytes.Equal(value, Tombstone) || bytes.Equal(stored, Tombstone)) {
+		panic(ErrTombstoneValue)
+	}
+}
+
+func (t *Trie) deleteWithTombstone(key []byte) bool {
+	stored, found, err := t.get(key)
+	if err != nil {
+		panic(err)
+	}
+	if !found || t.isTombstone(stored) {
+		return false
+	}
+
+	t.put(key, Tombstone)
+	t.releaseValue(stored)
+	t.nodeIndex = nil
+	t.debugCheckInvariants()
+	t.writeAuditDelete(key)
+	return true
+}
+
+// Compact removes the keys deleted in tombstone mode from the trie, and returns
+// the number of keys removed. After compaction the trie has the same hash as if
+// the keys were deleted without tombstones, so the proofs of the tombstones
+// don't verify under the new root anymore.
+// An audit record is written for each key removed.
+// Compact returns ErrNotCovered, and leaves the trie unchanged, if removing a
+// key needs a node only known by its hash, which happens in a trie created by
+// NewTrieFromProof.
+func (t *Trie) Compact() (int, error) {
+	keys := make([][]byte, 0)
+	it := t.NodeIterator()
+	for it.Next() {
+		if leaf, ok := it.Node().(*LeafNode); ok && t.isTombstone(leaf.Value) {
+			keys = append(keys, ToBytes(appendNibbles(it.Path(), leaf.Path...)))
+		}
+		if branch, ok := it.Node().(*BranchNode); ok && t.isTombstone(branch.Value) {
+			keys = append(keys, ToBytes(it.Path()))
+		}
+	}
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	// deleteNode doesn't modify the nodes, so the trie is only updated once
+	// all the keys are removed
+	roots, err := compactRoots(t.root, keys)
+	if err != nil {
+		return 0, err
+	}
+	for i, key := range keys {
+		t.root = roots[i]
+		t.writeAuditCompact(key)
+	}
+	t.nodeIndex = nil
+	t.debugCheckInvariants()
+	return len(keys), nil
+}
+
+// compactRoots removes the keys one by one from the trie with the given root,
+// and returns the root after each removal. It returns ErrNotCovered if a
+// removal reaches a node only known by its hash.
+func compactRoots(root Node, keys [][]byte) (roots []Node, err error) {
+	defer recoverNotCovered(&err)
+
+	roots = make([]Node, 0, len(keys))
+	for _, key := range keys {
+		root, _ = deleteNode(root, FromBytes(key))
+		roots = append(roots, root)
+	}
+	return roots, nil
+}
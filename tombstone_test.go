@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTombstones(t *testing.T) {
+	tr := NewTrie()
+	tr.EnableTombstones()
+	expected := NewTrie()
+	for i := 0; i < 50; i++ {
+		key, value := testKey(i), testValue(i)
+		tr.Put(key, value)
+		if i%3 != 0 {
+			expected.Put(key, value)
+		}
+	}
+	for i := 0; i < 50; i += 3 {
+		require.True(t, tr.Delete(testKey(i)))
+	}
+
+	t.Run("should not find deleted keys", func(t *testing.T) {
+		_, found := tr.Get([]byte("key3"))
+		require.False(t, found)
+		require.False(t, tr.Has([]byte("key3")))
+		require.False(t, tr.Delete([]byte("key3")))
+
+		pairs, err := tr.Scan([]byte{})
+		require.NoError(t, err)
+		expectedPairs, err := expected.Scan([]byte{})
+		require.NoError(t, err)
+		require.Equal(t, expectedPairs, pairs)
+	})
+
+	t.Run("should prove the deletion with the tombstone", func(t *testing.T) {
+		require.NotEqual(t, expected.Hash(), tr.Hash())
+		proof, found := tr.Prove([]byte("key3"))
+		require.True(t, found)
+		val, err := VerifyProof(tr.Hash(), []byte("key3"), proof)
+		require.NoError(t, err)
+		require.Equal(t, Tombstone, val)
+	})
+
+	t.Run("should restore the key when put again", func(t *testing.T) {
+		tr.Put([]byte("key3"), []byte("value3"))
+		val, found := tr.Get([]byte("key3"))
+		require.True(t, found)
+		require.Equal(t, []byte("value3"), val)
+		tr.Delete([]byte("key3"))
+	})
+
+	t.Run("should remove the tombstones when compacting", func(t *testing.T) {
+		var audit bytes.Buffer
+		tr.SetAuditWriter(&audit)
+		removed, err := tr.Compact()
+		require.NoError(t, err)
+		require.Equal(t, 17, removed)
+		require.Equal(t, expected.Hash(), tr.Hash())
+
+		records, err := ReadAuditRecords(&audit)
+		require.NoError(t, err)
+		require.Len(t, records, 17)
+		keyHashes := make([][]byte, 0)
+		for _, record := range records {
+			require.True(t, record.Deleted)
+			require.True(t, record.Compacted)
+			keyHashes = append(keyHashes, record.KeyHash)
+		}
+		expectedKeyHashes := make([][]byte, 0)
+		for i := 0; i < 50; i += 3 {
+			expectedKeyHashes = append(expectedKeyHashes, Keccak256(testKey(i)))
+		}
+		require.ElementsMatch(t, expectedKeyHashes, keyHashes)
+		require.Equal(t, expected.Hash(), []byte(records[len(records)-1].Root))
+
+		require.NoError(t, tr.CheckInvariants())
+		removed, err = tr.Compact()
+		require.NoError(t, err)
+		require.Equal(t, 0, removed)
+	})
+
+	t.Run("should not put the tombstone value", func(t *testing.T) {
+		require.PanicsWithValue(t, ErrTombstoneValue, func() {
+			tr.Put([]byte("key1"), Tombstone)
+		})
+		val, found := tr.Get([]byte("key1"))
+		require.True(t, found)
+		require.Equal(t, []byte("value1"), val)
+	})
+}
+
+func TestTombstonesWithValueCommitments(t *testing.T) {
+	tr := NewTrieWithValueCommitments()
+	tr.EnableTombstones()
+	tr.Put([]byte{1, 2}, []byte("hello"))
+
+	// the value whose commitment is Tombstone
+	require.PanicsWithValue(t, ErrTombstoneValue, func() {
+		tr.Put([]byte{1, 2}, []byte("merkle-patricia-trie tombstone"))
+	})
+	val, found := tr.Get([]byte{1, 2})
+	require.True(t, found)
+	require.Equal(t, []byte("hello"), val)
+
+	// Tombstone itself is committed to by its hash, but is still reserved
+	require.PanicsWithValue(t, ErrTombstoneValue, func() {
+		tr.Put([]byte{1, 2}, Tombstone)
+	})
+}
+
+func TestCompactPartialTrie(t *testing.T) {
+	tr := NewTrie()
+	tr.EnableTombstones()
+	tr.Put([]byte{1, 2, 3}, []byte("hello"))
+	tr.Put([]byte{1, 2, 3, 4}, []byte("world"))
+	// long enough for the leaf to be referenced by its hash
+	tr.Put([]byte{1, 2, 5}, bytes.Repeat([]byte("trie"), 10))
+	require.True(t, tr.Delete([]byte{1, 2, 3}))
+	require.True(t, tr.Delete([]byte{1, 2, 3, 4}))
+
+	proof, found := tr.Prove([]byte{1, 2, 3, 4})
+	require.True(t, found)
+	partial, err := NewTrieFromProof(tr.Hash(), proof)
+	require.NoError(t, err)
+	partial.EnableTombstones()
+
+	// removing {1, 2, 3} succeeds, but removing {1, 2, 3, 4} needs to merge
+	// the leaf of {1, 2, 5} which is only known by its hash
+	removed, err := partial.Compact()
+	require.Equal(t, ErrNotCovered, err)
+	require.Equal(t, 0, removed)
+	require.Equal(t, tr.Hash(), partial.Hash())
+}
@@ -12,36 +12,23 @@ import (
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/stretchr/testify/require"
 )
 
 func TestTransactionRootAndProof(t *testing.T) {
-
-	trie := NewTrie()
-
 	txs := TransactionsJSON(t)
 
-	for i, tx := range txs {
-		// key is the encoding of the index as the unsigned integer type
-		key, err := rlp.EncodeToBytes(uint(i))
-		require.NoError(t, err)
-
-		transaction := FromEthTransaction(tx)
-
-		// value is the RLP encoding of a transaction
-		rlp, err := transaction.GetRLP()
-		require.NoError(t, err)
-
-		trie.Put(key, rlp)
-	}
-
 	// the transaction root for block 10467135
 	// https://api.etherscan.io/api?module=proxy&action=eth_getBlockByNumber&tag=0x9fb73f&boolean=true&apikey=YourApiKeyToken
 	transactionRoot, err := hex.DecodeString("bb345e208bda953c908027a45aa443d6cab6b8d2fd64e83ec52f1008ddeafa58")
 	require.NoError(t, err)
 
+	trie, err := TransactionsTrieFromBlockJSON(blockJSON(t, hexutil.Encode(transactionRoot)))
+	require.NoError(t, err)
+
 	t.Run("merkle root hash should match with 10467135's transactionRoot", func(t *testing.T) {
 		// transaction root should match with block 10467135's transactionRoot
 		require.Equal(t, transactionRoot, trie.Hash())
@@ -212,22 +199,11 @@ func TestTrieWithHash(t *testing.T) {
 func TestTrieWithBlockTxs(t *testing.T) {
 	txs := TransactionsJSON(t)
 
-	trie := NewTrie()
-	for i, tx := range txs {
-		key, err := rlp.EncodeToBytes(uint(i))
-		require.NoError(t, err)
-
-		transaction := FromEthTransaction(tx)
-
-		rlp, err := transaction.GetRLP()
-		require.NoError(t, err)
-
-		trie.Put(key, rlp)
-	}
-
-	txRootHash := fmt.Sprintf("%x", types.DeriveSha(types.Transactions(txs)))
-	fmt.Printf("txRootHash: %v\n", txRootHash)
-	require.Equal(t, txRootHash, fmt.Sprintf("%x", trie.Hash()))
+	txRootHash := types.DeriveSha(types.Transactions(txs))
+	fmt.Printf("txRootHash: %x\n", txRootHash)
+	trie, err := TransactionsTrieFromBlockJSON(blockJSON(t, txRootHash.Hex()))
+	require.NoError(t, err)
+	require.Equal(t, txRootHash.Bytes(), trie.Hash())
 }
 
 func Test130Items(t *testing.T) {
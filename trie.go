@@ -1,15 +1,61 @@
 package main
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
 
 type Trie struct {
 	root Node
+
+	audit    *json.Encoder
+	auditErr error
+
+	// values holds the values of a trie created by NewTrieWithValueCommitments,
+	// keyed by their hash.
+	values map[string]committedValue
+
+	encodeValue func([]byte) []byte
+	decodeValue func([]byte) []byte
+
+	// nodeIndex maps node hashes to their paths, see LocateNodes. It is built
+	// by the first read needing it, under nodeIndexMu.
+	nodeIndexMu sync.Mutex
+	nodeIndex   map[string][][]Nibble
+
+	// tombstones is true in tombstone mode, see EnableTombstones
+	tombstones bool
+
+	// panicHook and operations are for the diagnostics, see SetPanicHook.
+	// Reads record operations too, operationsMu lets them run concurrently.
+	panicHook    func(*Diagnostics)
+	operationsMu sync.Mutex
+	operations   []Operation
 }
 
 func NewTrie() *Trie {
 	return &Trie{}
 }
 
+// SetValueHooks sets the functions applied to values on their way in and
+// out of the trie: Put stores encode(value), and Get returns decode(stored).
+// The hash and proofs are over the encoded values. Passing nil hooks removes them.
+func (t *Trie) SetValueHooks(encode func([]byte) []byte, decode func([]byte) []byte) {
+	t.encodeValue = encode
+	t.decodeValue = decode
+}
+
+// NewTrieWithValueCommitments creates a trie whose leaves store Keccak256(value)
+// rather than the value, which is kept in a side table instead. Proofs are
+// smaller since they only carry the value hash, while Get still returns the
+// value itself.
+func NewTrieWithValueCommitments() *Trie {
+	return &Trie{
+		values: make(map[string]committedValue),
+	}
+}
+
 func (t *Trie) Hash() []byte {
 	if IsEmptyNode(t.root) {
 		return EmptyNodeHash
@@ -17,25 +63,120 @@ func (t *Trie) Hash() []byte {
 	return t.root.Hash()
 }
 
+// Get returns the value of the key, which is a view, see GetView.
+// It also returns false if the key can't be looked up in a trie created by
+// NewTrieFromProof, use TryGet to tell it apart from a missing key.
 func (t *Trie) Get(key []byte) ([]byte, bool) {
+	return t.GetView(key)
+}
+
+// Has returns whether the key exists, without resolving its value, which saves
+// decoding it when value hooks are set. Like Get, it returns false if the key
+// can't be looked up, see TryGet.
+func (t *Trie) Has(key []byte) bool {
+	value, found, err := t.get(key)
+	return err == nil && found && !t.isTombstone(value)
+}
+
+// GetView returns the value of the key without copying it. The returned slice
+// may be shared with the trie: it must not be modified, and is only valid until
+// the next update of the trie.
+func (t *Trie) GetView(key []byte) ([]byte, bool) {
+	value, found, _ := t.TryGet(key)
+	return value, found
+}
+
+// GetCopy returns a copy of the value of the key, which the caller is free to modify.
+func (t *Trie) GetCopy(key []byte) ([]byte, bool) {
+	value, found := t.GetView(key)
+	if !found {
+		return nil, false
+	}
+	return append([]byte{}, value...), true
+}
+
+// TryGet is like Get, but returns ErrNotCovered if the key can't be looked up
+// because its path reaches a node only known by its hash, which happens in a
+// trie created by NewTrieFromProof.
+func (t *Trie) TryGet(key []byte) ([]byte, bool, error) {
+	if t.panicHook != nil {
+		defer t.recoverPanic(t.recordOperation("get", key))
+	}
+
+	value, found, err := t.get(key)
+	if err != nil {
+		return nil, false, err
+	}
+	if !found || t.isTombstone(value) {
+		return nil, false, nil
+	}
+	return t.resolveValue(value), true, nil
+}
+
+// resolveValue returns the value for the value stored in a leaf, by looking up
+// value commitments and decoding the value with the value hooks.
+func (t *Trie) resolveValue(stored []byte) []byte {
+	value := stored
+	if t.values != nil {
+		value = t.values[string(value)].value
+	}
+	if t.decodeValue != nil {
+		value = t.decodeValue(value)
+	}
+	return value
+}
+
+// committedValue is a value of the side table of value commitments, with the
+// number of leaves committing to it.
+type committedValue struct {
+	value []byte
+	refs  int
+}
+
+// retainValue records that one more leaf commits to the value with the hash.
+func (t *Trie) retainValue(hash []byte, value []byte) {
+	committed := t.values[string(hash)]
+	t.values[string(hash)] = committedValue{value: value, refs: committed.refs + 1}
+}
+
+// releaseValue records that one less leaf stores the given value, and drops
+// the value it commits to from the side table once no leaf does.
+func (t *Trie) releaseValue(stored []byte) {
+	if t.values == nil {
+		return
+	}
+	committed, ok := t.values[string(stored)]
+	if !ok {
+		// a tombstone
+		return
+	}
+	if committed.refs <= 1 {
+		delete(t.values, string(stored))
+		return
+	}
+	committed.refs--
+	t.values[string(stored)] = committed
+}
+
+func (t *Trie) get(key []byte) ([]byte, bool, error) {
 	node := t.root
 	nibbles := FromBytes(key)
 	for {
 		if IsEmptyNode(node) {
-			return nil, false
+			return nil, false, nil
 		}
 
 		if leaf, ok := node.(*LeafNode); ok {
 			matched := PrefixMatchedLen(leaf.Path, nibbles)
 			if matched != len(leaf.Path) || matched != len(nibbles) {
-				return nil, false
+				return nil, false, nil
 			}
-			return leaf.Value, true
+			return leaf.Value, true, nil
 		}
 
 		if branch, ok := node.(*BranchNode); ok {
 			if len(nibbles) == 0 {
-				return branch.Value, branch.HasValue()
+				return branch.Value, branch.HasValue(), nil
 			}
 
 			b, remaining := nibbles[0], nibbles[1:]
@@ -49,7 +190,7 @@ func (t *Trie) Get(key []byte) ([]byte, bool) {
 			// E 01020304
 			//   010203
 			if matched < len(ext.Path) {
-				return nil, false
+				return nil, false, nil
 			}
 
 			nibbles = nibbles[matched:]
@@ -57,16 +198,73 @@ func (t *Trie) Get(key []byte) ([]byte, bool) {
 			continue
 		}
 
+		if _, ok := node.(HashNode); ok {
+			return nil, false, ErrNotCovered
+		}
+
 		panic("not found")
 	}
 }
 
 // Put adds a key value pair to the trie
+// The empty key is valid, its value is stored on the root: in a LeafNode with an
+// empty path, or as the value of the root BranchNode.
 // In general, the rule is:
 // - When stopped at an EmptyNode, replace it with a new LeafNode with the remaining path.
 // - When stopped at a LeafNode, convert it to an ExtensionNode and add a new branch and a new LeafNode.
 // - When stopped at an ExtensionNode, convert it to another ExtensionNode with shorter path and create a new BranchNode points to the ExtensionNode.
+// Put panics with ErrNotCovered if the path reaches a node only known by its hash,
+// see TryPut.
+// In tombstone mode, Put panics with ErrTombstoneValue if the value, or the value
+// stored in the leaf for it, is Tombstone.
 func (t *Trie) Put(key []byte, value []byte) {
+	if t.panicHook != nil {
+		defer t.recoverPanic(t.recordOperation("put", key))
+	}
+
+	stored := value
+	if t.encodeValue != nil {
+		stored = t.encodeValue(stored)
+	}
+	if t.values != nil {
+		hash := Keccak256(stored)
+		t.checkNotTombstone(value, hash)
+		old, found, _ := t.get(key)
+		t.put(key, hash)
+		t.retainValue(hash, stored)
+		if found {
+			t.releaseValue(old)
+		}
+	} else {
+		t.checkNotTombstone(value, stored)
+		t.put(key, stored)
+	}
+	t.nodeIndex = nil
+	t.debugCheckInvariants()
+	t.writeAudit(key, value)
+}
+
+// TryPut is like Put, but returns ErrNotCovered instead of panicking if the path
+// reaches a node only known by its hash, which happens in a trie created by
+// NewTrieFromProof. The trie is left unchanged in that case.
+func (t *Trie) TryPut(key []byte, value []byte) (err error) {
+	defer recoverNotCovered(&err)
+	t.Put(key, value)
+	return nil
+}
+
+// PutOrDelete puts the key value pair like Put, but deletes the key if the
+// value is empty, which is how Ethereum clears a key, go-ethereum's
+// Trie.Update included.
+func (t *Trie) PutOrDelete(key []byte, value []byte) {
+	if len(value) == 0 {
+		t.Delete(key)
+		return
+	}
+	t.Put(key, value)
+}
+
+func (t *Trie) put(key []byte, value []byte) {
 	// need to use pointer, so that I can update root in place without
 	// keeping trace of the parent node
 	node := &t.root
@@ -196,7 +394,163 @@ func (t *Trie) Put(key []byte, value []byte) {
 			continue
 		}
 
+		if _, ok := (*node).(HashNode); ok {
+			panic(ErrNotCovered)
+		}
+
 		panic("unknown type")
 	}
 
 }
+
+// Delete removes the key from the trie, and returns whether the key existed.
+// The trie is restructured so that its hash is the same as if the key was never
+// put:
+// - A BranchNode left with a single child and no value is merged with the child, by prefixing the child's path with the branch nibble.
+// - A BranchNode left with only a value is converted into a LeafNode with an empty path.
+// - An ExtensionNode pointing to a LeafNode or an ExtensionNode is merged with it.
+// Delete panics with ErrNotCovered if the path reaches a node only known by its hash,
+// see TryDelete.
+// In tombstone mode, see EnableTombstones, the key is not removed but its value
+// is replaced by Tombstone.
+func (t *Trie) Delete(key []byte) bool {
+	if t.panicHook != nil {
+		defer t.recoverPanic(t.recordOperation("delete", key))
+	}
+
+	if t.tombstones {
+		return t.deleteWithTombstone(key)
+	}
+
+	stored, _, _ := t.get(key)
+	root, deleted := deleteNode(t.root, FromBytes(key))
+	if !deleted {
+		return false
+	}
+
+	t.root = root
+	t.releaseValue(stored)
+	t.nodeIndex = nil
+	t.debugCheckInvariants()
+	t.writeAuditDelete(key)
+	return true
+}
+
+// TryDelete is like Delete, but returns ErrNotCovered instead of panicking if
+// the key can't be deleted because it needs a node only known by its hash,
+// which happens in a trie created by NewTrieFromProof. The trie is left
+// unchanged in that case.
+func (t *Trie) TryDelete(key []byte) (deleted bool, err error) {
+	defer recoverNotCovered(&err)
+	return t.Delete(key), nil
+}
+
+// deleteNode removes the value at the nibbles under the given node, and returns
+// the node to replace it with. The nodes are not modified, the changed ones are
+// copied, so that the trie is left as it was if deleteNode panics.
+func deleteNode(node Node, nibbles []Nibble) (Node, bool) {
+	if IsEmptyNode(node) {
+		return node, false
+	}
+
+	if leaf, ok := node.(*LeafNode); ok {
+		matched := PrefixMatchedLen(leaf.Path, nibbles)
+		if matched != len(leaf.Path) || matched != len(nibbles) {
+			return node, false
+		}
+		return nil, true
+	}
+
+	if branch, ok := node.(*BranchNode); ok {
+		if len(nibbles) == 0 {
+			if !branch.HasValue() {
+				return node, false
+			}
+			updated := *branch
+			updated.RemoveValue()
+			return collapseBranch(&updated), true
+		}
+
+		b, remaining := nibbles[0], nibbles[1:]
+		child, deleted := deleteNode(branch.Branches[b], remaining)
+		if !deleted {
+			return node, false
+		}
+		updated := *branch
+		updated.SetBranch(b, child)
+		return collapseBranch(&updated), true
+	}
+
+	if ext, ok := node.(*ExtensionNode); ok {
+		matched := PrefixMatchedLen(ext.Path, nibbles)
+		if matched < len(ext.Path) {
+			return node, false
+		}
+
+		next, deleted := deleteNode(ext.Next, nibbles[matched:])
+		if !deleted {
+			return node, false
+		}
+		return joinPath(ext.Path, next), true
+	}
+
+	if _, ok := node.(HashNode); ok {
+		panic(ErrNotCovered)
+	}
+
+	panic("unknown type")
+}
+
+// collapseBranch returns the node to replace a branch node with after one of
+// its children or its value was removed.
+func collapseBranch(branch *BranchNode) Node {
+	children := 0
+	last := 0
+	for i, child := range branch.Branches {
+		if !IsEmptyNode(child) {
+			children++
+			last = i
+		}
+	}
+
+	if branch.HasValue() {
+		if children == 0 {
+			return NewLeafNodeFromNibbles([]Nibble{}, branch.Value)
+		}
+		return branch
+	}
+
+	if children > 1 {
+		return branch
+	}
+
+	if children == 0 {
+		return nil
+	}
+
+	return joinPath([]Nibble{Nibble(last)}, branch.Branches[last])
+}
+
+// joinPath returns a node that reaches the given node through the given path,
+// merging the path into the node's own path if it has one.
+func joinPath(path []Nibble, node Node) Node {
+	if IsEmptyNode(node) {
+		return nil
+	}
+
+	if leaf, ok := node.(*LeafNode); ok {
+		return NewLeafNodeFromNibbles(appendNibbles(path, leaf.Path...), leaf.Value)
+	}
+
+	if ext, ok := node.(*ExtensionNode); ok {
+		return NewExtensionNode(appendNibbles(path, ext.Path...), ext.Next)
+	}
+
+	if _, ok := node.(HashNode); ok {
+		// the node could be a leaf or an extension node whose path would need
+		// to be merged
+		panic(ErrNotCovered)
+	}
+
+	return NewExtensionNode(path, node)
+}
@@ -2,8 +2,10 @@ package main
 
 import (
 	"fmt"
+	"math/rand"
 	"testing"
 
+	ethtrie "github.com/ethereum/go-ethereum/trie"
 	"github.com/stretchr/testify/require"
 )
 
@@ -11,6 +13,48 @@ func hexEqual(t *testing.T, hex string, bytes []byte) {
 	require.Equal(t, hex, fmt.Sprintf("%x", bytes))
 }
 
+// testKey and testValue are the i-th key and value of the tries returned by
+// newTestTrie.
+func testKey(i int) []byte {
+	return []byte(fmt.Sprintf("key%v", i))
+}
+
+func testValue(i int) []byte {
+	return []byte(fmt.Sprintf("value%v", i))
+}
+
+// testKeys returns the keys of the trie returned by newTestTrie(t, n).
+func testKeys(n int) [][]byte {
+	keys := make([][]byte, 0, n)
+	for i := 0; i < n; i++ {
+		keys = append(keys, testKey(i))
+	}
+	return keys
+}
+
+// newTestTrie returns a trie with the keys key0 to key<n-1>, and the values
+// value0 to value<n-1>.
+func newTestTrie(t *testing.T, n int) *Trie {
+	t.Helper()
+	tr := NewTrie()
+	for i := 0; i < n; i++ {
+		tr.Put(testKey(i), testValue(i))
+	}
+	return tr
+}
+
+// newPartialTestTrie returns the trie built from the proof of key7 in
+// newTestTrie(t, 50), whose other keys are under nodes only known by their hash.
+func newPartialTestTrie(t *testing.T) *Trie {
+	t.Helper()
+	tr := newTestTrie(t, 50)
+	proof, ok := tr.Prove(testKey(7))
+	require.True(t, ok)
+	partial, err := NewTrieFromProof(tr.Hash(), proof)
+	require.NoError(t, err)
+	return partial
+}
+
 // check basic key-value mapping
 func TestGetPut(t *testing.T) {
 	t.Run("should get nothing if key does not exist", func(t *testing.T) {
@@ -27,6 +71,36 @@ func TestGetPut(t *testing.T) {
 		require.Equal(t, val, []byte("hello"))
 	})
 
+	t.Run("should not change the trie when modifying a copied value", func(t *testing.T) {
+		trie := NewTrie()
+		trie.Put([]byte{1, 2, 3, 4}, []byte("hello"))
+		hash := trie.Hash()
+
+		val, found := trie.GetCopy([]byte{1, 2, 3, 4})
+		require.Equal(t, true, found)
+		require.Equal(t, []byte("hello"), val)
+		val[0] = 'j'
+
+		view, found := trie.GetView([]byte{1, 2, 3, 4})
+		require.Equal(t, true, found)
+		require.Equal(t, []byte("hello"), view)
+		require.Equal(t, hash, trie.Hash())
+
+		_, found = trie.GetCopy([]byte("notexist"))
+		require.Equal(t, false, found)
+	})
+
+	t.Run("should check whether key exist", func(t *testing.T) {
+		trie := NewTrie()
+		trie.Put([]byte{1, 2, 3, 4}, []byte("hello"))
+		trie.Put([]byte{1, 2, 3, 4, 5, 6}, []byte("world"))
+		require.Equal(t, true, trie.Has([]byte{1, 2, 3, 4}))
+		require.Equal(t, true, trie.Has([]byte{1, 2, 3, 4, 5, 6}))
+		require.Equal(t, false, trie.Has([]byte{1, 2, 3}))
+		require.Equal(t, false, trie.Has([]byte{1, 2, 3, 4, 5}))
+		require.Equal(t, false, trie.Has([]byte("notexist")))
+	})
+
 	t.Run("should get updated value", func(t *testing.T) {
 		trie := NewTrie()
 		trie.Put([]byte{1, 2, 3, 4}, []byte("hello"))
@@ -307,3 +381,276 @@ func TestPutExtensionMore(t *testing.T) {
 
 	require.Equal(t, ext.Hash(), trie.Hash())
 }
+
+// the empty key is stored on the root: either as a LeafNode with an empty path,
+// or as the value of the root BranchNode, same as go-ethereum.
+func TestEmptyKey(t *testing.T) {
+	keySets := [][][]byte{
+		{{}},
+		{{}, {1, 2}},
+		{{1, 2}, {}},
+		{{1, 2}, {1, 3}, {}},
+		{{}, {1, 2}, {1, 3}},
+		{{0x12}, {0x13}, {}},
+	}
+
+	for _, keys := range keySets {
+		tr := NewTrie()
+		ethTrie := new(ethtrie.Trie)
+		for _, key := range keys {
+			value := append([]byte("value"), key...)
+			tr.Put(key, value)
+			ethTrie.Update(key, value)
+		}
+
+		require.Equal(t, ethTrie.Hash().Bytes(), tr.Hash())
+
+		val, found := tr.Get([]byte{})
+		require.True(t, found)
+		require.Equal(t, []byte("value"), val)
+
+		proof, found := tr.Prove([]byte{})
+		require.True(t, found)
+		val, err := VerifyProof(tr.Hash(), []byte{}, proof)
+		require.NoError(t, err)
+		require.Equal(t, []byte("value"), val)
+	}
+
+	t.Run("should not find the empty key if it was not put", func(t *testing.T) {
+		tr := NewTrie()
+		tr.Put([]byte{1, 2}, []byte("hello"))
+		tr.Put([]byte{1, 3}, []byte("world"))
+		_, found := tr.Get([]byte{})
+		require.False(t, found)
+		_, found = tr.Prove([]byte{})
+		require.False(t, found)
+	})
+}
+
+func TestValueCommitments(t *testing.T) {
+	tr := NewTrieWithValueCommitments()
+	tr.Put([]byte{1, 2, 3, 4}, []byte("hello"))
+	tr.Put([]byte{1, 2}, []byte("world"))
+
+	t.Run("should get the value rather than its hash", func(t *testing.T) {
+		val, found := tr.Get([]byte{1, 2, 3, 4})
+		require.True(t, found)
+		require.Equal(t, []byte("hello"), val)
+
+		_, found = tr.Get([]byte{1, 2, 3})
+		require.False(t, found)
+	})
+
+	t.Run("should commit to the value hash", func(t *testing.T) {
+		hashed := NewTrie()
+		hashed.Put([]byte{1, 2, 3, 4}, Keccak256([]byte("hello")))
+		hashed.Put([]byte{1, 2}, Keccak256([]byte("world")))
+		require.Equal(t, hashed.Hash(), tr.Hash())
+	})
+
+	t.Run("should prove the value hash", func(t *testing.T) {
+		proof, found := tr.Prove([]byte{1, 2})
+		require.True(t, found)
+		val, err := VerifyProof(tr.Hash(), []byte{1, 2}, proof)
+		require.NoError(t, err)
+		require.Equal(t, Keccak256([]byte("world")), val)
+	})
+
+	t.Run("should drop values no leaf commits to", func(t *testing.T) {
+		tr := NewTrieWithValueCommitments()
+		tr.Put([]byte{1, 2, 3, 4}, []byte("hello"))
+		tr.Put([]byte{1, 2}, []byte("hello"))
+		require.Len(t, tr.values, 1)
+
+		tr.Put([]byte{1, 2, 3, 4}, []byte("world"))
+		require.Len(t, tr.values, 2)
+
+		tr.Put([]byte{1, 2}, []byte("world"))
+		require.Len(t, tr.values, 1)
+
+		tr.Put([]byte{1, 2}, []byte("world"))
+		require.True(t, tr.Delete([]byte{1, 2, 3, 4}))
+		val, found := tr.Get([]byte{1, 2})
+		require.True(t, found)
+		require.Equal(t, []byte("world"), val)
+
+		require.True(t, tr.Delete([]byte{1, 2}))
+		require.Len(t, tr.values, 0)
+	})
+
+	t.Run("should drop the values of keys deleted with a tombstone", func(t *testing.T) {
+		tr := NewTrieWithValueCommitments()
+		tr.EnableTombstones()
+		tr.Put([]byte{1, 2}, []byte("hello"))
+		require.True(t, tr.Delete([]byte{1, 2}))
+		require.Len(t, tr.values, 0)
+		removed, err := tr.Compact()
+		require.NoError(t, err)
+		require.Equal(t, 1, removed)
+	})
+}
+
+func TestValueHooks(t *testing.T) {
+	encode := func(value []byte) []byte {
+		return append([]byte("tag:"), value...)
+	}
+	decode := func(encoded []byte) []byte {
+		return encoded[len("tag:"):]
+	}
+
+	tr := NewTrie()
+	tr.SetValueHooks(encode, decode)
+	tr.Put([]byte{1, 2, 3, 4}, []byte("hello"))
+	tr.Put([]byte{1, 2}, []byte("world"))
+
+	t.Run("should get the decoded value", func(t *testing.T) {
+		val, found := tr.Get([]byte{1, 2, 3, 4})
+		require.True(t, found)
+		require.Equal(t, []byte("hello"), val)
+	})
+
+	t.Run("should check existence without decoding the value", func(t *testing.T) {
+		panicking := NewTrie()
+		panicking.SetValueHooks(encode, func([]byte) []byte {
+			panic("should not decode")
+		})
+		panicking.Put([]byte{1, 2}, []byte("world"))
+		require.True(t, panicking.Has([]byte{1, 2}))
+	})
+
+	t.Run("should hash and prove the encoded value", func(t *testing.T) {
+		encoded := NewTrie()
+		encoded.Put([]byte{1, 2, 3, 4}, []byte("tag:hello"))
+		encoded.Put([]byte{1, 2}, []byte("tag:world"))
+		require.Equal(t, encoded.Hash(), tr.Hash())
+
+		proof, found := tr.Prove([]byte{1, 2})
+		require.True(t, found)
+		val, err := VerifyProof(tr.Hash(), []byte{1, 2}, proof)
+		require.NoError(t, err)
+		require.Equal(t, []byte("tag:world"), val)
+	})
+
+	t.Run("should apply the hooks around value commitments", func(t *testing.T) {
+		committed := NewTrieWithValueCommitments()
+		committed.SetValueHooks(encode, decode)
+		committed.Put([]byte{1, 2}, []byte("world"))
+
+		val, found := committed.Get([]byte{1, 2})
+		require.True(t, found)
+		require.Equal(t, []byte("world"), val)
+
+		hashed := NewTrie()
+		hashed.Put([]byte{1, 2}, Keccak256([]byte("tag:world")))
+		require.Equal(t, hashed.Hash(), committed.Hash())
+	})
+}
+
+func TestDelete(t *testing.T) {
+	t.Run("should not find the deleted key", func(t *testing.T) {
+		trie := NewTrie()
+		trie.Put([]byte{1, 2, 3, 4}, []byte("hello"))
+		require.True(t, trie.Delete([]byte{1, 2, 3, 4}))
+		_, found := trie.Get([]byte{1, 2, 3, 4})
+		require.False(t, found)
+		require.Equal(t, EmptyNodeHash, trie.Hash())
+
+		require.False(t, trie.Delete([]byte{1, 2, 3, 4}))
+	})
+
+	t.Run("should collapse a branch node with a single leaf child", func(t *testing.T) {
+		trie := NewTrie()
+		trie.Put([]byte{1, 2, 3, 4}, []byte("hello"))
+		trie.Put([]byte{1, 2, 3, 4, 5, 6}, []byte("world"))
+		require.True(t, trie.Delete([]byte{1, 2, 3, 4}))
+
+		expected := NewTrie()
+		expected.Put([]byte{1, 2, 3, 4, 5, 6}, []byte("world"))
+		require.Equal(t, expected.Hash(), trie.Hash())
+		_, ok := trie.root.(*LeafNode)
+		require.True(t, ok)
+	})
+
+	t.Run("should collapse a branch node with only a value", func(t *testing.T) {
+		trie := NewTrie()
+		trie.Put([]byte{1, 2, 3, 4}, []byte("hello"))
+		trie.Put([]byte{1, 2, 3, 4, 5, 6}, []byte("world"))
+		require.True(t, trie.Delete([]byte{1, 2, 3, 4, 5, 6}))
+
+		expected := NewTrie()
+		expected.Put([]byte{1, 2, 3, 4}, []byte("hello"))
+		require.Equal(t, expected.Hash(), trie.Hash())
+	})
+
+	t.Run("should not delete a key that is only a prefix", func(t *testing.T) {
+		trie := NewTrie()
+		trie.Put([]byte{1, 2, 3, 4}, []byte("hello1"))
+		trie.Put([]byte{1, 2, 3, 5}, []byte("hello2"))
+		hash := trie.Hash()
+		require.False(t, trie.Delete([]byte{1, 2, 3}))
+		require.False(t, trie.Delete([]byte{1, 2, 3, 4, 5}))
+		require.Equal(t, hash, trie.Hash())
+	})
+
+	t.Run("should match go-ethereum after random puts and deletes", func(t *testing.T) {
+		r := rand.New(rand.NewSource(1))
+		trie := NewTrie()
+		ethTrie := new(ethtrie.Trie)
+		keys := make([][]byte, 0)
+		for i := 0; i < 2000; i++ {
+			if len(keys) > 0 && r.Intn(3) == 0 {
+				index := r.Intn(len(keys))
+				key := keys[index]
+				keys = append(keys[:index], keys[index+1:]...)
+				require.True(t, trie.Delete(key))
+				ethTrie.Delete(key)
+			} else {
+				// short random keys, so that keys are prefixes of each other
+				key := make([]byte, r.Intn(4))
+				r.Read(key)
+				value := make([]byte, 1+r.Intn(40))
+				r.Read(value)
+				if _, found := trie.Get(key); !found {
+					keys = append(keys, key)
+				}
+				trie.Put(key, value)
+				ethTrie.Update(key, value)
+			}
+			require.Equal(t, ethTrie.Hash().Bytes(), trie.Hash(), "step %v", i)
+		}
+
+		for _, key := range keys {
+			require.True(t, trie.Delete(key))
+		}
+		require.Equal(t, EmptyNodeHash, trie.Hash())
+	})
+}
+
+func TestPutOrDelete(t *testing.T) {
+	t.Run("should delete the key when the value is empty", func(t *testing.T) {
+		trie := NewTrie()
+		trie.PutOrDelete([]byte{1, 2, 3, 4}, []byte("hello"))
+		trie.PutOrDelete([]byte{1, 2, 3, 4, 5, 6}, []byte("world"))
+		trie.PutOrDelete([]byte{1, 2, 3, 4}, nil)
+		trie.PutOrDelete([]byte{5, 6}, []byte{})
+
+		expected := NewTrie()
+		expected.Put([]byte{1, 2, 3, 4, 5, 6}, []byte("world"))
+		require.Equal(t, expected.Hash(), trie.Hash())
+	})
+
+	t.Run("should match go-ethereum when clearing values", func(t *testing.T) {
+		r := rand.New(rand.NewSource(1))
+		trie := NewTrie()
+		ethTrie := new(ethtrie.Trie)
+		for i := 0; i < 500; i++ {
+			key := make([]byte, r.Intn(4))
+			r.Read(key)
+			value := make([]byte, r.Intn(3)*(1+r.Intn(40)))
+			r.Read(value)
+			trie.PutOrDelete(key, value)
+			ethTrie.Update(key, value)
+			require.Equal(t, ethTrie.Hash().Bytes(), trie.Hash(), "step %v", i)
+		}
+	})
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Account is the state of an account, as stored in the world state trie.
+type Account struct {
+	Nonce       uint64
+	Balance     *big.Int
+	StorageHash common.Hash
+	CodeHash    common.Hash
+}
+
+// VerifyAccountProof verifies the proof of an account under the state root,
+// such as the accountProof returned by eth_getProof, and decodes the account.
+// It returns nil if the proof shows that the account does not exist.
+func VerifyAccountProof(stateRoot []byte, address common.Address, proof Proof) (*Account, error) {
+	value, err := VerifyProof(stateRoot, crypto.Keccak256(address.Bytes()), proof)
+	if err != nil {
+		return nil, fmt.Errorf("could not verify account proof for %x: %w", address, err)
+	}
+
+	if len(value) == 0 {
+		return nil, nil
+	}
+
+	var account Account
+	err = rlp.DecodeBytes(value, &account)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode account %x: %w", address, err)
+	}
+	return &account, nil
+}
+
+// VerifyStorageProof verifies the proof of a storage slot under the storage
+// root of a contract, and decodes the value of the slot.
+// It returns 0 if the proof shows that the slot is not set.
+func VerifyStorageProof(storageRoot []byte, slot common.Hash, proof Proof) (*big.Int, error) {
+	value, err := VerifyProof(storageRoot, crypto.Keccak256(slot.Bytes()), proof)
+	if err != nil {
+		return nil, fmt.Errorf("could not verify storage proof for slot %x: %w", slot, err)
+	}
+
+	if len(value) == 0 {
+		return new(big.Int), nil
+	}
+
+	var content []byte
+	err = rlp.DecodeBytes(value, &content)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode storage slot %x: %w", slot, err)
+	}
+	return new(big.Int).SetBytes(content), nil
+}
+
+// VerifyTxProof verifies the proof of the transaction at the given index under
+// the transactions root of a block, and decodes the transaction, which can be
+// a legacy or a typed transaction, see DecodeTransaction.
+// It returns nil if the proof shows that there is no transaction at the index.
+func VerifyTxProof(txRoot []byte, index int, proof Proof) (*RPCTransaction, error) {
+	value, err := VerifyProof(txRoot, LogKey(index), proof)
+	if err != nil {
+		return nil, fmt.Errorf("could not verify transaction proof for index %v: %w", index, err)
+	}
+
+	if len(value) == 0 {
+		return nil, nil
+	}
+
+	tx, err := DecodeTransaction(value)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode transaction %v: %w", index, err)
+	}
+	return tx, nil
+}
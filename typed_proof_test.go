@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyAccountAndStorageProof(t *testing.T) {
+	byteValue, err := ioutil.ReadFile("storage_proof_slot_0.json")
+	require.NoError(t, err)
+
+	var response EthGetProofResponse
+	require.NoError(t, json.Unmarshal(byteValue, &response))
+	result := response.Result
+
+	// https://etherscan.io/block/11045195
+	stateRootHash := common.HexToHash("0x8c571da4c95e212e508c98a50c2640214d23f66e9a591523df6140fd8d113f29")
+	address := common.HexToAddress("0xcca577ee56d30a444c73f8fc8d5ce34ed1c7da8b")
+
+	t.Run("should decode the verified account", func(t *testing.T) {
+		account, err := VerifyAccountProof(stateRootHash.Bytes(), address, NewProofDBFromHex(result.AccountProof))
+		require.NoError(t, err)
+		require.Equal(t, uint64(result.Nonce), account.Nonce)
+		require.Equal(t, 0, result.Balance.ToInt().Cmp(account.Balance))
+		require.Equal(t, result.StorageHash, account.StorageHash)
+		require.Equal(t, result.CodeHash, account.CodeHash)
+	})
+
+	t.Run("should fail to verify the account under another root", func(t *testing.T) {
+		_, err := VerifyAccountProof(EmptyNodeHash, address, NewProofDBFromHex(result.AccountProof))
+		require.Error(t, err)
+	})
+
+	t.Run("should decode the verified storage slot", func(t *testing.T) {
+		storageProof := result.StorageProof[0]
+		slot := common.BytesToHash(storageProof.Key)
+		value, err := VerifyStorageProof(result.StorageHash.Bytes(), slot, NewProofDBFromHex(storageProof.Proof))
+		require.NoError(t, err)
+		require.Equal(t, common.FromHex("0xde74da73d5102a796559933296c73e7d1c6f37fb"), value.Bytes())
+	})
+}
+
+func TestVerifyTxProof(t *testing.T) {
+	txs := TransactionsJSON(t)
+	log := NewLog()
+	for _, tx := range txs {
+		rlp, err := FromEthTransaction(tx).GetRLP()
+		require.NoError(t, err)
+		log.Append(rlp)
+	}
+
+	proof, found := log.ProveItem(30)
+	require.True(t, found)
+
+	tx, err := VerifyTxProof(log.Root(), 30, proof)
+	require.NoError(t, err)
+	require.Equal(t, hexutil.Uint64(LegacyTxType), *tx.Type)
+	require.Equal(t, txs[30].Hash(), tx.Hash)
+	require.Equal(t, txs[30].Nonce(), uint64(tx.Nonce))
+	encoded, err := tx.Encode()
+	require.NoError(t, err)
+	expected, err := FromEthTransaction(txs[30]).GetRLP()
+	require.NoError(t, err)
+	require.Equal(t, expected, encoded)
+}
+
+func TestVerifyContractCreationTxProof(t *testing.T) {
+	legacyType := hexutil.Uint64(LegacyTxType)
+	creation := RPCTransaction{
+		Type:     &legacyType,
+		Nonce:    7,
+		GasPrice: (*hexutil.Big)(big.NewInt(1000)),
+		Gas:      53000,
+		Value:    (*hexutil.Big)(big.NewInt(0)),
+		Input:    []byte{0x60, 0x00},
+		V:        (*hexutil.Big)(big.NewInt(27)),
+		R:        (*hexutil.Big)(big.NewInt(4)),
+		S:        (*hexutil.Big)(big.NewInt(5)),
+	}
+	encoded, err := creation.Encode()
+	require.NoError(t, err)
+
+	log := NewLog()
+	log.Append(encoded)
+	proof, found := log.ProveItem(0)
+	require.True(t, found)
+
+	tx, err := VerifyTxProof(log.Root(), 0, proof)
+	require.NoError(t, err)
+	require.Equal(t, legacyType, *tx.Type)
+	require.Nil(t, tx.To)
+	require.Equal(t, common.BytesToHash(Keccak256(encoded)), tx.Hash)
+
+	reencoded, err := tx.Encode()
+	require.NoError(t, err)
+	require.Equal(t, encoded, reencoded)
+}
+
+func TestVerifyTypedTxProof(t *testing.T) {
+	to := common.HexToAddress("0x897c3dec007e1bcd7b8dcc1f304c2246eea68537")
+	accessListType := hexutil.Uint64(AccessListTxType)
+	dynamicFeeType := hexutil.Uint64(DynamicFeeTxType)
+	txs := []RPCTransaction{
+		{
+			Type:     &accessListType,
+			ChainID:  (*hexutil.Big)(big.NewInt(1)),
+			Nonce:    1,
+			GasPrice: (*hexutil.Big)(big.NewInt(1000)),
+			Gas:      21000,
+			To:       &to,
+			Value:    (*hexutil.Big)(big.NewInt(5)),
+			Input:    []byte{},
+			AccessList: []AccessTuple{{
+				Address:     to,
+				StorageKeys: []common.Hash{common.BigToHash(common.Big1)},
+			}},
+			V: (*hexutil.Big)(big.NewInt(1)),
+			R: (*hexutil.Big)(big.NewInt(2)),
+			S: (*hexutil.Big)(big.NewInt(3)),
+		},
+		{
+			Type:                 &dynamicFeeType,
+			ChainID:              (*hexutil.Big)(big.NewInt(1)),
+			Nonce:                2,
+			MaxPriorityFeePerGas: (*hexutil.Big)(big.NewInt(10)),
+			MaxFeePerGas:         (*hexutil.Big)(big.NewInt(2000)),
+			Gas:                  53000,
+			Value:                (*hexutil.Big)(big.NewInt(0)),
+			Input:                []byte{0x60, 0x00},
+			AccessList:           []AccessTuple{},
+			V:                    (*hexutil.Big)(big.NewInt(0)),
+			R:                    (*hexutil.Big)(big.NewInt(4)),
+			S:                    (*hexutil.Big)(big.NewInt(5)),
+		},
+	}
+
+	log := NewLog()
+	encoded := make([][]byte, 0)
+	for _, tx := range txs {
+		data, err := tx.Encode()
+		require.NoError(t, err)
+		encoded = append(encoded, data)
+		log.Append(data)
+	}
+	log.Append([]byte{0x7f, 0xc0})
+
+	t.Run("should decode typed transactions", func(t *testing.T) {
+		for i := range txs {
+			proof, found := log.ProveItem(i)
+			require.True(t, found)
+
+			tx, err := VerifyTxProof(log.Root(), i, proof)
+			require.NoError(t, err)
+			require.Equal(t, *txs[i].Type, *tx.Type)
+			require.Equal(t, txs[i].To, tx.To)
+			require.Equal(t, common.BytesToHash(Keccak256(encoded[i])), tx.Hash)
+
+			reencoded, err := tx.Encode()
+			require.NoError(t, err)
+			require.Equal(t, encoded[i], reencoded)
+		}
+	})
+
+	t.Run("should fail on unknown transaction types", func(t *testing.T) {
+		proof, found := log.ProveItem(2)
+		require.True(t, found)
+		_, err := VerifyTxProof(log.Root(), 2, proof)
+		require.Error(t, err)
+	})
+}
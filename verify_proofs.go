@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// ProofJob is a proof to verify for a key under a root hash.
+type ProofJob struct {
+	RootHash []byte
+	Key      []byte
+	Proof    Proof
+}
+
+// ProofResult is the outcome of verifying a ProofJob, with the same meaning as
+// the return values of VerifyProof.
+type ProofResult struct {
+	Value []byte
+	Err   error
+}
+
+// VerifyProofs verifies the proofs of all the jobs using one worker per CPU.
+// The result for jobs[i] is returned at index i.
+func VerifyProofs(jobs []ProofJob) []ProofResult {
+	return VerifyProofsWithWorkers(jobs, runtime.NumCPU())
+}
+
+// VerifyProofsWithWorkers verifies the proofs of all the jobs using the given
+// number of workers. The result for jobs[i] is returned at index i.
+// A job whose verification panics, for instance because its proof is nil,
+// gets the panic as its error.
+func VerifyProofsWithWorkers(jobs []ProofJob, workers int) []ProofResult {
+	if workers < 1 {
+		workers = 1
+	}
+
+	results := make([]ProofResult, len(jobs))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = verifyJob(jobs[i])
+			}
+		}()
+	}
+
+	for i := range jobs {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results
+}
+
+func verifyJob(job ProofJob) (result ProofResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = ProofResult{Err: fmt.Errorf("proof verification panicked: %v", r)}
+		}
+	}()
+
+	value, err := VerifyProof(job.RootHash, job.Key, job.Proof)
+	return ProofResult{Value: value, Err: err}
+}
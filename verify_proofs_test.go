@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyProofs(t *testing.T) {
+	tr := newTestTrie(t, 50)
+	rootHash := tr.Hash()
+
+	jobs := make([]ProofJob, 0)
+	for _, key := range testKeys(50) {
+		proof, ok := tr.Prove(key)
+		require.True(t, ok)
+		jobs = append(jobs, ProofJob{RootHash: rootHash, Key: key, Proof: proof})
+	}
+
+	// a proof verified against a wrong root hash should fail
+	jobs[10].RootHash = EmptyNodeHash
+	// a nil proof or a proof that panics should fail without affecting the
+	// other jobs
+	jobs[20].Proof = nil
+	jobs[30].Proof = panickingProof{ProofDB: NewProofDB()}
+
+	results := VerifyProofsWithWorkers(jobs, 4)
+	require.Len(t, results, len(jobs))
+	for i, result := range results {
+		if i == 10 || i == 20 || i == 30 {
+			require.Error(t, result.Err)
+			continue
+		}
+		require.NoError(t, result.Err)
+		require.Equal(t, []byte(fmt.Sprintf("value%v", i)), result.Value)
+	}
+
+	require.Equal(t, results, VerifyProofs(jobs))
+}
+
+type panickingProof struct {
+	*ProofDB
+}
+
+func (p panickingProof) Get(key []byte) ([]byte, error) {
+	panic("corrupted proof")
+}